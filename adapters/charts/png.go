@@ -0,0 +1,206 @@
+package charts
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"polling-system/domain"
+)
+
+// Size of a card, the image size OpenGraph link previews expect.
+const (
+	CardWidth  = 1200
+	CardHeight = 630
+)
+
+const (
+	cardMargin   = 64
+	cardRow      = 76
+	cardBar      = 26
+	cardTitle    = 50
+	cardLabel    = 28
+	cardFooter   = 26
+	cardMaxTitle = 2
+)
+
+var (
+	ink   = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	muted = color.RGBA{0x66, 0x66, 0x66, 0xff}
+	track = color.RGBA{0xee, 0xee, 0xee, 0xff}
+)
+
+// The Go fonts are compiled into the binary, so cards render the same
+// everywhere without any fonts installed.
+var (
+	regularFont = sync.OnceValues(func() (*opentype.Font, error) { return opentype.Parse(goregular.TTF) })
+	boldFont    = sync.OnceValues(func() (*opentype.Font, error) { return opentype.Parse(gobold.TTF) })
+)
+
+// newFace returns a face at the given pixel size. Faces keep per-use state,
+// so every card gets its own.
+func newFace(f func() (*opentype.Font, error), size float64) (font.Face, error) {
+	parsed, err := f()
+	if err != nil {
+		return nil, fmt.Errorf("error loading font: %w", err)
+	}
+	return opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// PNG writes a CardWidth×CardHeight image of the poll's question and a bar
+// per option with its count and percentage, for link previews. Options that
+// don't fit are left out, leaders first kept.
+func PNG(w io.Writer, result domain.PollResult) error {
+	title, err := newFace(boldFont, cardTitle)
+	if err != nil {
+		return err
+	}
+	defer title.Close()
+	label, err := newFace(regularFont, cardLabel)
+	if err != nil {
+		return err
+	}
+	defer label.Close()
+	footer, err := newFace(regularFont, cardFooter)
+	if err != nil {
+		return err
+	}
+	defer footer.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, CardWidth, CardHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	fill(img, image.Rect(0, 0, 12, CardHeight), hexColor(palette[0]))
+
+	inner := CardWidth - 2*cardMargin
+	y := cardMargin
+	for _, line := range wrapWidth(title, result.Poll.Question, inner, cardMaxTitle) {
+		y += cardTitle
+		text(img, title, ink, cardMargin, y, line)
+		y += 12
+	}
+	y += 24
+
+	options, total := shares(result)
+	footerY := CardHeight - cardMargin + cardFooter/2
+	rows := max(0, (footerY-cardFooter-16-y)/cardRow)
+	shown, hidden := leaders(options, rows)
+
+	most := 0
+	for _, o := range shown {
+		most = max(most, o.count)
+	}
+	for _, o := range shown {
+		value := countLabel(o)
+		valueWidth := font.MeasureString(label, value).Ceil()
+		text(img, label, ink, cardMargin, y+cardLabel, fitWidth(label, o.label, inner-valueWidth-24))
+		text(img, label, ink, CardWidth-cardMargin-valueWidth, y+cardLabel, value)
+
+		barY := y + cardLabel + 12
+		fill(img, image.Rect(cardMargin, barY, CardWidth-cardMargin, barY+cardBar), track)
+		if most > 0 {
+			length := inner * o.count / most
+			fill(img, image.Rect(cardMargin, barY, cardMargin+length, barY+cardBar), hexColor(o.color))
+		}
+		y += cardRow
+	}
+
+	summary := votes(total)
+	if hidden > 0 {
+		summary += fmt.Sprintf(" · %d more options", hidden)
+	}
+	if !result.Poll.Open(time.Now()) {
+		summary += " · closed"
+	}
+	text(img, footer, muted, cardMargin, footerY, summary)
+
+	return png.Encode(w, img)
+}
+
+// leaders keeps at most n options, dropping those with the fewest votes,
+// and returns them in poll order with the number dropped.
+func leaders(options []share, n int) ([]share, int) {
+	if len(options) <= n {
+		return options, 0
+	}
+	order := make([]int, len(options))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return options[b].count - options[a].count })
+	keep := order[:n]
+	slices.Sort(keep)
+
+	shown := make([]share, 0, n)
+	for _, i := range keep {
+		shown = append(shown, options[i])
+	}
+	return shown, len(options) - n
+}
+
+func text(dst draw.Image, face font.Face, c color.Color, x, y int, s string) {
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+func fill(dst draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// wrapWidth breaks text into at most maxLines lines no wider than width
+// pixels, ending the last with an ellipsis if text was cut short.
+func wrapWidth(face font.Face, text string, width, maxLines int) []string {
+	var lines []string
+	var line string
+	words := strings.Fields(text)
+	for i, word := range words {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line == "" || font.MeasureString(face, candidate).Ceil() <= width {
+			line = candidate
+			continue
+		}
+		if len(lines) == maxLines-1 {
+			return append(lines, fitWidth(face, strings.Join(append([]string{line}, words[i:]...), " "), width))
+		}
+		lines = append(lines, line)
+		line = word
+	}
+	return append(lines, fitWidth(face, line, width))
+}
+
+// fitWidth shortens s with an ellipsis until it is at most width pixels wide.
+func fitWidth(face font.Face, s string, width int) string {
+	if font.MeasureString(face, s).Ceil() <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if short := strings.TrimSpace(string(runes)) + "…"; font.MeasureString(face, short).Ceil() <= width {
+			return short
+		}
+	}
+	return ""
+}
+
+// hexColor parses a palette entry such as "#4e79a7".
+func hexColor(s string) color.RGBA {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+}
@@ -0,0 +1,67 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+
+	"polling-system/domain"
+)
+
+func TestPNG(t *testing.T) {
+	many := domain.PollResult{Poll: domain.Poll{Question: "Pick one"}, Results: map[string]int{}}
+	for i := 0; i < 12; i++ {
+		option := fmt.Sprintf("Option %d", i)
+		many.Poll.Options = append(many.Poll.Options, option)
+		many.Results[option] = i
+	}
+
+	for name, result := range map[string]domain.PollResult{
+		"votes":    testResult(map[string]int{"Yes": 2, "No": 1}),
+		"no votes": testResult(nil),
+		"overflow": many,
+	} {
+		var buf bytes.Buffer
+		if err := PNG(&buf, result); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: expected a valid PNG, got %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != CardWidth || b.Dy() != CardHeight {
+			t.Errorf("%s: expected %dx%d, got %v", name, CardWidth, CardHeight, b)
+		}
+	}
+}
+
+func TestLeaders(t *testing.T) {
+	options := []share{{label: "a", count: 1}, {label: "b", count: 5}, {label: "c", count: 0}, {label: "d", count: 3}}
+	shown, hidden := leaders(options, 2)
+	if hidden != 2 || len(shown) != 2 || shown[0].label != "b" || shown[1].label != "d" {
+		t.Errorf("Expected b and d in poll order with 2 hidden, got %+v, %d", shown, hidden)
+	}
+	if shown, hidden := leaders(options, 10); hidden != 0 || len(shown) != 4 {
+		t.Errorf("Expected every option to fit, got %+v, %d", shown, hidden)
+	}
+}
+
+func TestWrapWidth(t *testing.T) {
+	face, err := newFace(boldFont, cardTitle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer face.Close()
+
+	lines := wrapWidth(face, "Which of these many options should we pick for the next release of the product, and why?", 500, 2)
+	if len(lines) != 2 {
+		t.Fatalf("Expected two lines, got %q", lines)
+	}
+	if last := []rune(lines[1]); last[len(last)-1] != '…' {
+		t.Errorf("Expected the cut title to end with an ellipsis, got %q", lines[1])
+	}
+	if lines := wrapWidth(face, "Short?", 500, 2); len(lines) != 1 || lines[0] != "Short?" {
+		t.Errorf("Expected a short title on one line, got %q", lines)
+	}
+}
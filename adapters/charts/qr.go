@@ -0,0 +1,165 @@
+package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// Level is how much of a QR code can be damaged or covered and still scan,
+// traded against how dense the code gets.
+type Level string
+
+const (
+	// LevelL recovers about 7% of the code.
+	LevelL Level = "L"
+	// LevelM recovers about 15% of the code.
+	LevelM Level = "M"
+	// LevelQ recovers about 25% of the code.
+	LevelQ Level = "Q"
+	// LevelH recovers about 30% of the code, e.g. behind a logo.
+	LevelH Level = "H"
+)
+
+var levels = map[Level]qr.Level{LevelL: qr.L, LevelM: qr.M, LevelQ: qr.Q, LevelH: qr.H}
+
+// Bounds of QROptions.Size, in pixels.
+const (
+	DefaultQRSize = 512
+	MaxQRSize     = 1024
+)
+
+var (
+	ErrUnknownLevel = errors.New("error correction level must be L, M, Q or H")
+	ErrQRSize       = fmt.Errorf("QR code size must be between 1 and %d pixels", MaxQRSize)
+)
+
+// QROptions sets how a QR code is drawn.
+type QROptions struct {
+	// Size is the width and height in pixels, quiet zone included. Zero
+	// leaves an SVG free to scale and makes a PNG DefaultQRSize.
+	Size  int
+	Level Level
+}
+
+// ParseQROptions reads the size and level as given in a query; empty means
+// the default size and LevelM.
+func ParseQROptions(size, level string) (QROptions, error) {
+	opts := QROptions{Level: LevelM}
+	if level != "" {
+		opts.Level = Level(strings.ToUpper(level))
+		if _, ok := levels[opts.Level]; !ok {
+			return QROptions{}, fmt.Errorf("%w, not %q", ErrUnknownLevel, level)
+		}
+	}
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 || n > MaxQRSize {
+			return QROptions{}, fmt.Errorf("%w, not %q", ErrQRSize, size)
+		}
+		opts.Size = n
+	}
+	return opts, nil
+}
+
+// quietZone is the blank border, in modules, that scanners need around a
+// QR code.
+const quietZone = 4
+
+func encodeQR(text string, opts QROptions) (*qr.Code, error) {
+	if opts.Level == "" {
+		opts.Level = LevelM
+	}
+	level, ok := levels[opts.Level]
+	if !ok {
+		return nil, fmt.Errorf("%w, not %q", ErrUnknownLevel, opts.Level)
+	}
+	if opts.Size < 0 || opts.Size > MaxQRSize {
+		return nil, fmt.Errorf("%w, not %d", ErrQRSize, opts.Size)
+	}
+	code, err := qr.Encode(text, level)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding QR code: %w", err)
+	}
+	return code, nil
+}
+
+// QRSVG writes text, typically a URL, as a QR code in an SVG document.
+func QRSVG(w io.Writer, text string, opts QROptions) error {
+	code, err := encodeQR(text, opts)
+	if err != nil {
+		return err
+	}
+	side := code.Size + 2*quietZone
+
+	var doc bytes.Buffer
+	doc.WriteString(`<svg xmlns="http://www.w3.org/2000/svg"`)
+	if opts.Size > 0 {
+		fmt.Fprintf(&doc, ` width="%d" height="%d"`, opts.Size, opts.Size)
+	}
+	fmt.Fprintf(&doc, ` viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", side, side)
+	fmt.Fprintf(&doc, `<title>%s</title>`+"\n", esc(text))
+	fmt.Fprintf(&doc, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", side, side)
+	doc.WriteString(`<path fill="#000000" d="`)
+	// One subpath per run of dark modules keeps the document small
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for code.Black(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&doc, "M%d %dh%dv1h-%dz", x+quietZone, y+quietZone, run, run)
+			x += run
+		}
+	}
+	doc.WriteString("\"/>\n</svg>\n")
+
+	_, err = w.Write(doc.Bytes())
+	return err
+}
+
+// QRPNG writes text as a black and white PNG QR code. Every module is the
+// same whole number of pixels so the code stays sharp; the rest of the size
+// widens the quiet zone. Sizes too small for one pixel per module grow to fit.
+func QRPNG(w io.Writer, text string, opts QROptions) error {
+	code, err := encodeQR(text, opts)
+	if err != nil {
+		return err
+	}
+	size := opts.Size
+	if size == 0 {
+		size = DefaultQRSize
+	}
+	side := code.Size + 2*quietZone
+	scale := max(1, size/side)
+	size = max(size, side)
+	offset := (size - code.Size*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+			for py := offset + y*scale; py < offset+(y+1)*scale; py++ {
+				row := img.Pix[py*img.Stride:]
+				for px := offset + x*scale; px < offset+(x+1)*scale; px++ {
+					row[px] = 1
+				}
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,151 @@
+package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"rsc.io/qr"
+)
+
+func TestQRSVG(t *testing.T) {
+	text := "https://polls.example.com/ui/vote.html?id=a&b"
+	var buf bytes.Buffer
+	if err := QRSVG(&buf, text, QROptions{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	wellFormed(t, buf.Bytes())
+	svg := buf.String()
+	if !strings.Contains(svg, "<title>https://polls.example.com/ui/vote.html?id=a&amp;b</title>") {
+		t.Errorf("Expected the escaped text as title, got\n%s", svg)
+	}
+
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := code.Size + 2*quietZone
+	if want := fmt.Sprintf(`viewBox="0 0 %d %d"`, side, side); !strings.Contains(svg, want) {
+		t.Errorf("Expected %s for a quiet zone of %d modules, got\n%s", want, quietZone, svg)
+	}
+
+	// Redraw the runs and compare them module by module with the code
+	dark := make(map[[2]int]bool)
+	for _, m := range regexp.MustCompile(`M(\d+) (\d+)h(\d+)`).FindAllStringSubmatch(svg, -1) {
+		x, _ := strconv.Atoi(m[1])
+		y, _ := strconv.Atoi(m[2])
+		run, _ := strconv.Atoi(m[3])
+		for i := 0; i < run; i++ {
+			dark[[2]int{x + i - quietZone, y - quietZone}] = true
+		}
+	}
+	for y := -quietZone; y < code.Size+quietZone; y++ {
+		for x := -quietZone; x < code.Size+quietZone; x++ {
+			if dark[[2]int{x, y}] != code.Black(x, y) {
+				t.Fatalf("Module (%d, %d): expected dark=%v", x, y, code.Black(x, y))
+			}
+		}
+	}
+}
+
+func TestQRSVGTooLong(t *testing.T) {
+	if err := QRSVG(&bytes.Buffer{}, strings.Repeat("x", 4000), QROptions{}); err == nil {
+		t.Error("Expected an error for text beyond the capacity of a QR code")
+	}
+}
+
+func TestParseQROptions(t *testing.T) {
+	tests := []struct {
+		size, level string
+		want        QROptions
+		err         error
+	}{
+		{"", "", QROptions{Level: LevelM}, nil},
+		{"300", "h", QROptions{Size: 300, Level: LevelH}, nil},
+		{"1", "L", QROptions{Size: 1, Level: LevelL}, nil},
+		{"", "X", QROptions{}, ErrUnknownLevel},
+		{"0", "", QROptions{}, ErrQRSize},
+		{"1024", "", QROptions{Size: 1024, Level: LevelM}, nil},
+		{"1025", "", QROptions{}, ErrQRSize},
+		{"big", "", QROptions{}, ErrQRSize},
+	}
+	for _, tt := range tests {
+		got, err := ParseQROptions(tt.size, tt.level)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("(%q, %q): expected error %v, got %v", tt.size, tt.level, tt.err, err)
+		}
+		if got != tt.want {
+			t.Errorf("(%q, %q): expected %+v, got %+v", tt.size, tt.level, tt.want, got)
+		}
+	}
+}
+
+func TestQRSVGOptions(t *testing.T) {
+	var low, high bytes.Buffer
+	if err := QRSVG(&low, "https://polls.example.com/", QROptions{Size: 300, Level: LevelL}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(low.String(), `width="300" height="300"`) {
+		t.Errorf("Expected a 300 pixel image, got\n%s", low.String())
+	}
+	if err := QRSVG(&high, "https://polls.example.com/", QROptions{Level: LevelH}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(high.String(), `<svg xmlns="http://www.w3.org/2000/svg" viewBox=`) {
+		t.Errorf("Expected an unsized image to scale, got\n%s", high.String())
+	}
+	if low.Len() >= high.Len() {
+		t.Error("Expected level H to make a denser code than level L")
+	}
+
+	if err := QRSVG(&bytes.Buffer{}, "x", QROptions{Level: "Z"}); !errors.Is(err, ErrUnknownLevel) {
+		t.Errorf("Expected ErrUnknownLevel, got %v", err)
+	}
+}
+
+func TestQRPNG(t *testing.T) {
+	text := "https://polls.example.com/ui/vote.html?id=1"
+	code, err := qr.Encode(text, qr.Q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := code.Size + 2*quietZone
+
+	tests := []struct {
+		size, want int
+	}{
+		{0, DefaultQRSize},
+		{300, 300},
+		{10, side},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := QRPNG(&buf, text, QROptions{Size: tt.size, Level: LevelQ}); err != nil {
+			t.Fatalf("%d: expected no error, got %v", tt.size, err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%d: expected a PNG, got %v", tt.size, err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.want || b.Dy() != tt.want {
+			t.Errorf("%d: expected %dx%d, got %v", tt.size, tt.want, tt.want, b)
+		}
+
+		// Sample the middle of every module, quiet zone included
+		scale := max(1, tt.want/side)
+		offset := (tt.want - code.Size*scale) / 2
+		for y := -quietZone; y < code.Size+quietZone; y++ {
+			for x := -quietZone; x < code.Size+quietZone; x++ {
+				r, _, _, _ := img.At(offset+x*scale+scale/2, offset+y*scale+scale/2).RGBA()
+				if dark := r == 0; dark != code.Black(x, y) {
+					t.Fatalf("%d: module (%d, %d): expected dark=%v", tt.size, x, y, code.Black(x, y))
+				}
+			}
+		}
+	}
+}
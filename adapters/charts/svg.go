@@ -0,0 +1,273 @@
+// Package charts renders poll results as images that can be embedded in
+// slides and chat messages without any client-side code.
+package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"polling-system/domain"
+)
+
+// Kind selects how SVG lays out a poll's results.
+type Kind string
+
+const (
+	// Bar draws one horizontal bar per option, scaled to the leading one.
+	Bar Kind = "bar"
+	// Pie draws each option's share as a slice, with a legend beside it.
+	Pie Kind = "pie"
+	// Stacked draws a single bar split into each option's share.
+	Stacked Kind = "stacked"
+)
+
+var ErrUnknownKind = errors.New("chart type must be bar, pie or stacked")
+
+// ParseKind reads a chart type as given in a query; empty means Bar.
+func ParseKind(s string) (Kind, error) {
+	switch kind := Kind(s); kind {
+	case "":
+		return Bar, nil
+	case Bar, Pie, Stacked:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("%w, not %q", ErrUnknownKind, s)
+	}
+}
+
+const (
+	width      = 800
+	margin     = 24
+	titleSize  = 26
+	titleLine  = 34
+	labelSize  = 16
+	titleChars = 52
+	emptyColor = "#d0d0d0"
+)
+
+// palette is Tableau 10, whose colours stay distinguishable on projectors.
+var palette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// share is one option's slice of the tally.
+type share struct {
+	label   string
+	count   int
+	percent float64
+	color   string
+}
+
+// shares lists the poll's options in order with their counts and their
+// percentage of all votes.
+func shares(result domain.PollResult) ([]share, int) {
+	total := 0
+	for _, option := range result.Poll.Options {
+		total += result.Results[option]
+	}
+	out := make([]share, len(result.Poll.Options))
+	for i, option := range result.Poll.Options {
+		out[i] = share{label: option, count: result.Results[option], color: palette[i%len(palette)]}
+		if total > 0 {
+			out[i].percent = 100 * float64(out[i].count) / float64(total)
+		}
+	}
+	return out, total
+}
+
+// SVG writes result as a standalone SVG document of the given kind, titled
+// with the poll's question and with each option labelled by its count and
+// percentage of the votes.
+func SVG(w io.Writer, result domain.PollResult, kind Kind) error {
+	options, total := shares(result)
+	title := wrap(result.Poll.Question, titleChars)
+	top := margin + len(title)*titleLine + margin/2
+
+	var body bytes.Buffer
+	var height int
+	switch kind {
+	case Bar:
+		height = barChart(&body, options, top)
+	case Pie:
+		height = pieChart(&body, options, total, top)
+	case Stacked:
+		height = stackedChart(&body, options, total, top)
+	default:
+		return fmt.Errorf("%w, not %q", ErrUnknownKind, kind)
+	}
+	height += margin + labelSize
+
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(&doc, `<title>%s</title>`+"\n", esc(result.Poll.Question))
+	fmt.Fprintf(&doc, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+	for i, line := range title {
+		fmt.Fprintf(&doc, `<text x="%d" y="%d" font-size="%d" font-weight="bold" fill="#222222">%s</text>`+"\n", margin, margin+titleSize+i*titleLine, titleSize, esc(line))
+	}
+	doc.Write(body.Bytes())
+	fmt.Fprintf(&doc, `<text x="%d" y="%d" font-size="%d" fill="#666666">%s</text>`+"\n", margin, height-margin, labelSize-2, votes(total))
+	doc.WriteString("</svg>\n")
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// barChart draws a labelled bar per option and returns the height used.
+func barChart(w io.Writer, options []share, top int) int {
+	const rowHeight, barHeight, labelWidth = 58, 26, 170
+	most := 0
+	for _, o := range options {
+		most = max(most, o.count)
+	}
+	span := float64(width - 2*margin - labelWidth)
+
+	y := top
+	for _, o := range options {
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-size="%d" fill="#222222">%s</text>`+"\n", margin, y+labelSize, labelSize, esc(o.label))
+		barY := y + labelSize + 8
+		length := 0.0
+		if most > 0 {
+			length = span * float64(o.count) / float64(most)
+		}
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%.2f" height="%d" fill="%s"/>`+"\n", margin, barY, length, barHeight, o.color)
+		fmt.Fprintf(w, `<text x="%.2f" y="%d" font-size="%d" fill="#222222">%s</text>`+"\n", float64(margin)+length+8, barY+barHeight-7, labelSize, countLabel(o))
+		y += rowHeight
+	}
+	return y
+}
+
+// pieChart draws the slices with a legend to their right and returns the
+// height used.
+func pieChart(w io.Writer, options []share, total, top int) int {
+	const radius = 150
+	cx, cy := float64(margin+radius), float64(top+radius)
+
+	if total == 0 {
+		fmt.Fprintf(w, `<circle cx="%.2f" cy="%.2f" r="%d" fill="%s"/>`+"\n", cx, cy, radius, emptyColor)
+	}
+	angle := -math.Pi / 2
+	for _, o := range options {
+		if o.count == 0 {
+			continue
+		}
+		if o.count == total {
+			// An arc cannot start and end at the same point
+			fmt.Fprintf(w, `<circle cx="%.2f" cy="%.2f" r="%d" fill="%s"/>`+"\n", cx, cy, radius, o.color)
+			break
+		}
+		sweep := 2 * math.Pi * float64(o.count) / float64(total)
+		large := 0
+		if sweep > math.Pi {
+			large = 1
+		}
+		x1, y1 := cx+radius*math.Cos(angle), cy+radius*math.Sin(angle)
+		angle += sweep
+		x2, y2 := cx+radius*math.Cos(angle), cy+radius*math.Sin(angle)
+		fmt.Fprintf(w, `<path d="M%.2f %.2f L%.2f %.2f A%d %d 0 %d 1 %.2f %.2f Z" fill="%s" stroke="#ffffff" stroke-width="2"/>`+"\n",
+			cx, cy, x1, y1, radius, radius, large, x2, y2, o.color)
+	}
+
+	legendTop := top + radius - len(options)*legendRow/2
+	legend(w, options, margin+2*radius+40, max(top, legendTop))
+	return max(top+2*radius, top+len(options)*legendRow)
+}
+
+// stackedChart draws one bar split by share with a legend below it and
+// returns the height used.
+func stackedChart(w io.Writer, options []share, total, top int) int {
+	const barHeight = 56
+	span := float64(width - 2*margin)
+
+	if total == 0 {
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%.2f" height="%d" fill="%s"/>`+"\n", margin, top, span, barHeight, emptyColor)
+	}
+	x := float64(margin)
+	for _, o := range options {
+		if o.count == 0 {
+			continue
+		}
+		length := span * float64(o.count) / float64(total)
+		fmt.Fprintf(w, `<rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s"/>`+"\n", x, top, length, barHeight, o.color)
+		// Label segments wide enough to hold their percentage
+		if length >= 56 {
+			fmt.Fprintf(w, `<text x="%.2f" y="%d" font-size="%d" fill="#ffffff" text-anchor="middle">%s</text>`+"\n", x+length/2, top+barHeight/2+labelSize/3, labelSize, percent(o.percent))
+		}
+		x += length
+	}
+
+	legend(w, options, margin, top+barHeight+margin)
+	return top + barHeight + margin + len(options)*legendRow
+}
+
+const legendRow = 30
+
+// legend lists each option's colour, label, count and percentage.
+func legend(w io.Writer, options []share, x, y int) {
+	for i, o := range options {
+		rowY := y + i*legendRow
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="18" height="18" fill="%s"/>`+"\n", x, rowY, o.color)
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-size="%d" fill="#222222">%s — %s</text>`+"\n", x+28, rowY+labelSize-1, labelSize, esc(o.label), countLabel(o))
+	}
+}
+
+// Summary describes the results in one line, e.g. for link previews:
+// "Yes 66.7% · No 33.3% · 3 votes".
+func Summary(result domain.PollResult) string {
+	options, total := shares(result)
+	parts := make([]string, 0, len(options)+1)
+	for _, o := range options {
+		parts = append(parts, fmt.Sprintf("%s %s", o.label, percent(o.percent)))
+	}
+	return strings.Join(append(parts, votes(total)), " · ")
+}
+
+func countLabel(o share) string {
+	return fmt.Sprintf("%d (%s)", o.count, percent(o.percent))
+}
+
+// percent shows whole percentages without a decimal.
+func percent(p float64) string {
+	if p == math.Trunc(p) {
+		return fmt.Sprintf("%.0f%%", p)
+	}
+	return fmt.Sprintf("%.1f%%", p)
+}
+
+func votes(total int) string {
+	if total == 1 {
+		return "1 vote"
+	}
+	return fmt.Sprintf("%d votes", total)
+}
+
+// wrap breaks text into lines of at most width runes at spaces; words
+// longer than a line are left whole.
+func wrap(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
@@ -0,0 +1,103 @@
+package charts
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"polling-system/domain"
+)
+
+func testResult(results map[string]int) domain.PollResult {
+	return domain.PollResult{
+		Poll:    domain.Poll{ID: "1", Question: "Ship <it> & tell?", Options: []string{"Yes", "No", "Maybe"}},
+		Results: results,
+	}
+}
+
+// wellFormed parses the document to the end so broken markup fails.
+func wellFormed(t *testing.T, svg []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Expected well-formed SVG, got %v:\n%s", err, svg)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	for _, kind := range []Kind{Bar, Pie, Stacked} {
+		var buf bytes.Buffer
+		if err := SVG(&buf, testResult(map[string]int{"Yes": 2, "No": 1}), kind); err != nil {
+			t.Fatalf("%s: expected no error, got %v", kind, err)
+		}
+		wellFormed(t, buf.Bytes())
+		svg := buf.String()
+		for _, want := range []string{"Ship &lt;it&gt; &amp; tell?", "66.7%", "33.3%", "3 votes"} {
+			if !strings.Contains(svg, want) {
+				t.Errorf("%s: expected %q in\n%s", kind, want, svg)
+			}
+		}
+	}
+}
+
+func TestSVGEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		results map[string]int
+		want    string
+	}{
+		{"no votes", nil, "0 votes"},
+		{"unanimous", map[string]int{"No": 4}, "100%"},
+	}
+	for _, tt := range tests {
+		for _, kind := range []Kind{Bar, Pie, Stacked} {
+			var buf bytes.Buffer
+			if err := SVG(&buf, testResult(tt.results), kind); err != nil {
+				t.Fatalf("%s %s: expected no error, got %v", tt.name, kind, err)
+			}
+			wellFormed(t, buf.Bytes())
+			if !strings.Contains(buf.String(), tt.want) || strings.Contains(buf.String(), "NaN") {
+				t.Errorf("%s %s: expected %q and no NaN in\n%s", tt.name, kind, tt.want, buf.String())
+			}
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if kind, err := ParseKind(""); err != nil || kind != Bar {
+		t.Errorf("Expected bar by default, got %q, %v", kind, err)
+	}
+	if kind, err := ParseKind("pie"); err != nil || kind != Pie {
+		t.Errorf("Expected pie, got %q, %v", kind, err)
+	}
+	if _, err := ParseKind("donut"); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("Expected ErrUnknownKind, got %v", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("Which of these options should we pick for the next release?", 20)
+	for _, line := range lines {
+		if len(line) > 20 {
+			t.Errorf("Expected lines of at most 20 characters, got %q", line)
+		}
+	}
+	if strings.Join(lines, " ") != "Which of these options should we pick for the next release?" {
+		t.Errorf("Expected wrapping to keep every word, got %q", lines)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	if got := Summary(testResult(map[string]int{"Yes": 2, "No": 1})); got != "Yes 66.7% · No 33.3% · Maybe 0% · 3 votes" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+func TestInProcess(t *testing.T) {
+	ctx := context.Background()
+	bus := NewInProcess()
+
+	var got []domain.TallyChanged
+	unsubscribe, _ := bus.Subscribe(func(event domain.TallyChanged) { got = append(got, event) })
+
+	_ = bus.Publish(ctx, domain.TallyChanged{PollID: "1", Origin: "a"})
+	unsubscribe()
+	_ = bus.Publish(ctx, domain.TallyChanged{PollID: "2", Origin: "a"})
+
+	if len(got) != 1 || got[0].PollID != "1" {
+		t.Errorf("Expected only the event published while subscribed, got %v", got)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := bus.Publish(canceled, domain.TallyChanged{PollID: "1"}); err == nil {
+		t.Error("Expected an error publishing with a canceled context")
+	}
+}
+
+func runNATSServer(t *testing.T) string {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+func TestNATSAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	url := runNATSServer(t)
+
+	buses := make([]*NATS, 2)
+	for i := range buses {
+		bus, err := NewNATS(url, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = bus.Close() })
+		buses[i] = bus
+	}
+	if err := buses[1].Ready(ctx); err != nil {
+		t.Errorf("Expected the bus to be ready, got %v", err)
+	}
+
+	received := make(chan domain.TallyChanged, 1)
+	unsubscribe, err := buses[1].Subscribe(func(event domain.TallyChanged) { received <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	// Other publishers on the subject must not break subscribers
+	_ = buses[0].conn.Publish(DefaultSubject, []byte("not json"))
+	if err := buses[0].Publish(ctx, domain.TallyChanged{PollID: "1", Origin: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-received:
+		if event.PollID != "1" || event.Origin != "a" {
+			t.Errorf("Unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the event to reach the other instance")
+	}
+}
+
+func TestNATSUnreachable(t *testing.T) {
+	if _, err := NewNATS("nats://127.0.0.1:1", ""); err == nil {
+		t.Error("Expected an error connecting to a server that is not running")
+	}
+}
+
+var (
+	_ ports.EventBus = (*InProcess)(nil)
+	_ ports.EventBus = (*NATS)(nil)
+)
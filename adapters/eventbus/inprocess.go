@@ -0,0 +1,49 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"polling-system/domain"
+)
+
+// InProcess delivers events synchronously to subscribers in the same
+// process. It is the default for single-instance deployments.
+type InProcess struct {
+	mu       sync.RWMutex
+	next     int
+	handlers map[int]func(domain.TallyChanged)
+}
+
+func NewInProcess() *InProcess {
+	return &InProcess{handlers: make(map[int]func(domain.TallyChanged))}
+}
+
+func (b *InProcess) Publish(ctx context.Context, event domain.TallyChanged) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *InProcess) Subscribe(handler func(domain.TallyChanged)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
+
+func (b *InProcess) Close() error {
+	return nil
+}
@@ -0,0 +1,85 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+
+	"polling-system/domain"
+)
+
+const DefaultSubject = "polling.tally"
+
+// NATS publishes events on a NATS subject so every instance connected to
+// the same server sees them. Delivery is at most once: an instance that is
+// disconnected misses events, which live update streams absorb since each
+// of them carries the full current state.
+type NATS struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNATS(url, subject string) (*NATS, error) {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	conn, err := nats.Connect(url, nats.Name("polling-system"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS at %s: %w", url, err)
+	}
+	return &NATS{conn: conn, subject: subject}, nil
+}
+
+func (b *NATS) Publish(ctx context.Context, event domain.TallyChanged) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.conn.Publish(b.subject, data)
+}
+
+func (b *NATS) Subscribe(handler func(domain.TallyChanged)) (func(), error) {
+	sub, err := b.conn.Subscribe(b.subject, func(msg *nats.Msg) {
+		var event domain.TallyChanged
+		if err := json.Unmarshal(msg.Data, &event); err != nil || event.PollID == "" {
+			return
+		}
+		handler(event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the server knows about the subscription before the caller
+	// relies on it
+	if err := b.conn.Flush(); err != nil {
+		_ = sub.Unsubscribe()
+		return nil, err
+	}
+	return func() { _ = sub.Unsubscribe() }, nil
+}
+
+// Ready reports whether the connection to the NATS server is up.
+func (b *NATS) Ready(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !b.conn.IsConnected() {
+		return fmt.Errorf("not connected to NATS (%s)", b.conn.Status())
+	}
+	return nil
+}
+
+// Close delivers pending messages and disconnects. While the server is
+// unreachable there is nothing to deliver them to, so it just closes.
+func (b *NATS) Close() error {
+	if !b.conn.IsConnected() {
+		b.conn.Close()
+		return nil
+	}
+	return b.conn.Drain()
+}
@@ -0,0 +1,139 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+func events(pollID string, from, n int) []domain.PollEvent {
+	var out []domain.PollEvent
+	for i := from; i < from+n; i++ {
+		e := domain.PollEvent{Seq: i, Type: domain.VoteCast, PollID: pollID, At: time.Now().UTC(), Option: "Yes"}
+		if i == 0 {
+			e.Type = domain.PollCreated
+			e.Option = ""
+			e.Poll = &domain.Poll{ID: pollID, Options: []string{"Yes", "No"}}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func testStore(t *testing.T, store ports.EventStore) {
+	ctx := context.Background()
+
+	if err := store.Append(ctx, "1", 0, events("1", 0, 2)...); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Append(ctx, "1", 1, events("1", 1, 1)...); !errors.Is(err, domain.ErrStreamConflict) {
+		t.Errorf("Expected ErrStreamConflict, got %v", err)
+	}
+	if err := store.Append(ctx, "1", 2, events("1", 3, 1)...); err == nil {
+		t.Error("Expected an error for an event that skips a sequence number")
+	}
+	if err := store.Append(ctx, "1", 2, events("1", 2, 1)...); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	got, err := store.Load(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != 3 || got[2].Seq != 2 {
+		t.Errorf("Expected 3 events in order, got %v", got)
+	}
+	if got, _ := store.Load(ctx, "2"); len(got) != 0 {
+		t.Errorf("Expected no events for an unknown poll, got %v", got)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	store, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	testStore(t, store)
+	_ = store.Append(ctx, "2", 0, events("2", 0, 1)...)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Reopening replays the log
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer reopened.Close()
+	first, _ := reopened.Load(ctx, "1")
+	second, _ := reopened.Load(ctx, "2")
+	if len(first) != 3 || len(second) != 1 || first[0].Poll == nil {
+		t.Errorf("Expected the logged events back, got %v and %v", first, second)
+	}
+	if err := reopened.Append(ctx, "1", 3, events("1", 3, 1)...); err != nil {
+		t.Errorf("Expected to keep appending after reopening, got %v", err)
+	}
+}
+
+func TestFileTornAppend(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	store, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = store.Append(ctx, "1", 0, events("1", 0, 2)...)
+
+	// A write that fails leaves nothing visible
+	writable := store.file
+	store.file, _ = os.Open(path)
+	if err := store.Append(ctx, "1", 2, events("1", 2, 1)...); err == nil {
+		t.Error("Expected an error when the log cannot be written")
+	}
+	if loaded, _ := store.Load(ctx, "1"); len(loaded) != 2 {
+		t.Errorf("Expected the failed append to stay invisible, got %d events", len(loaded))
+	}
+	_ = store.file.Close()
+	store.file = writable
+	_ = store.Close()
+
+	// A crash in the middle of an append leaves a line without its newline
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	_, _ = f.WriteString(`{"seq":2,"type":"vote_c`)
+	_ = f.Close()
+
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected a torn last line to be skipped, got %v", err)
+	}
+	if loaded, _ := reopened.Load(ctx, "1"); len(loaded) != 2 {
+		t.Errorf("Expected the 2 complete events back, got %d", len(loaded))
+	}
+	if err := reopened.Append(ctx, "1", 2, events("1", 2, 1)...); err != nil {
+		t.Errorf("Expected to keep appending, got %v", err)
+	}
+	_ = reopened.Close()
+
+	again, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected the torn line to have been cut off, got %v", err)
+	}
+	defer again.Close()
+	if loaded, _ := again.Load(ctx, "1"); len(loaded) != 3 {
+		t.Errorf("Expected 3 events after the repaired log, got %d", len(loaded))
+	}
+}
@@ -0,0 +1,117 @@
+package eventstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"polling-system/domain"
+)
+
+// File is a Memory store that also appends every event as a JSON line to a
+// log file, and replays that file when opened.
+type File struct {
+	*Memory
+	file *os.File
+	// size is the length of the log up to the last complete append.
+	size int64
+	// failed is set once a failed append could not be cut off again; later
+	// appends would follow its partial line, so they are refused.
+	failed error
+}
+
+func NewFile(path string) (*File, error) {
+	s := &File{Memory: NewMemory()}
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+// load replays the log. A last line without its newline is the tail of an
+// append that never completed, so it is cut off rather than failing.
+func (s *File) load(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(data) > 0 {
+				return os.Truncate(path, s.size)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var e domain.PollEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return fmt.Errorf("error reading event log %s line %d: %w", path, line, err)
+		}
+		if err := s.append(e.PollID, len(s.streams[e.PollID]), []domain.PollEvent{e}, nil); err != nil {
+			return fmt.Errorf("error reading event log %s line %d: %w", path, line, err)
+		}
+		s.size += int64(len(data))
+	}
+}
+
+// Append writes and syncs the events before they become visible, so an
+// acknowledged vote survives a crash.
+func (s *File) Append(ctx context.Context, pollID string, expected int, events ...domain.PollEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var data []byte
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(pollID, expected, events, func() error {
+		if s.failed != nil {
+			return s.failed
+		}
+		_, err := s.file.Write(data)
+		if err == nil {
+			err = s.file.Sync()
+		}
+		if err != nil {
+			// Cut off whatever part of the events reached the file, so they
+			// are not replayed although the append failed
+			if terr := s.file.Truncate(s.size); terr != nil {
+				s.failed = fmt.Errorf("event log left with a partial append: %w", terr)
+				return errors.Join(err, s.failed)
+			}
+			return err
+		}
+		s.size += int64(len(data))
+		return nil
+	})
+}
+
+func (s *File) Close() error {
+	return s.file.Close()
+}
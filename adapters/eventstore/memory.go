@@ -0,0 +1,59 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"polling-system/domain"
+)
+
+// Memory keeps event streams in memory.
+type Memory struct {
+	mu      sync.RWMutex
+	streams map[string][]domain.PollEvent
+}
+
+func NewMemory() *Memory {
+	return &Memory{streams: make(map[string][]domain.PollEvent)}
+}
+
+func (s *Memory) Append(ctx context.Context, pollID string, expected int, events ...domain.PollEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(pollID, expected, events, nil)
+}
+
+// append checks and adds events with s.mu held. persist, when set, runs
+// after the check and before the events become visible.
+func (s *Memory) append(pollID string, expected int, events []domain.PollEvent, persist func() error) error {
+	stream := s.streams[pollID]
+	if len(stream) != expected {
+		return fmt.Errorf("%w: poll %s has %d events, expected %d", domain.ErrStreamConflict, pollID, len(stream), expected)
+	}
+	for i, e := range events {
+		if e.PollID != pollID || e.Seq != expected+i {
+			return fmt.Errorf("event %d of poll %s does not continue the stream", e.Seq, e.PollID)
+		}
+	}
+	if persist != nil {
+		if err := persist(); err != nil {
+			return err
+		}
+	}
+	s.streams[pollID] = append(stream, events...)
+	return nil
+}
+
+func (s *Memory) Load(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.streams[pollID]), nil
+}
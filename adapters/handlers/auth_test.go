@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	handler := RequireToken("secret", ok)
+
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/create_poll", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != tt.want {
+			t.Errorf("Authorization %q: got status %d want %d", tt.header, rr.Code, tt.want)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	RequireToken("", ok)(rr, httptest.NewRequest("POST", "/create_poll", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected an empty token to disable auth, got status %d", rr.Code)
+	}
+}
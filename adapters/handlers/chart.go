@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"polling-system/adapters/charts"
+)
+
+// ChartHandler serves /polls/{id}/chart.svg: the current results drawn as a
+// ?type=bar (the default), pie or stacked chart.
+func (h *HTTPHandler) ChartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	kind, err := charts.ParseKind(r.URL.Query().Get("type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.pollService.GetResults(r.Context(), pollID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	if setResultsValidators(w, r, result) {
+		return
+	}
+
+	var svg bytes.Buffer
+	if err := charts.SVG(&svg, result, kind); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = w.Write(svg.Bytes())
+}
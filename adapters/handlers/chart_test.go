@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestChartHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"", http.StatusOK},
+		{"?type=bar", http.StatusOK},
+		{"?type=pie", http.StatusOK},
+		{"?type=stacked", http.StatusOK},
+		{"?type=donut", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ChartHandler(rr, httptest.NewRequest("GET", "/polls/1/chart.svg"+tt.query, nil))
+		if rr.Code != tt.status {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.status, rr.Code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "image/svg+xml" {
+			t.Errorf("%q: expected an SVG content type, got %q", tt.query, ct)
+		}
+		if body := rr.Body.String(); !strings.HasPrefix(body, "<svg") || !strings.Contains(body, "Test?") || !strings.Contains(body, "100%") {
+			t.Errorf("%q: expected a chart titled with the question, got\n%s", tt.query, body)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/polls/1/chart.svg", nil)
+	req.Header.Set("If-None-Match", `W/"1"`)
+	rr := httptest.NewRecorder()
+	handler.ChartHandler(rr, req)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for an unchanged tally, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ChartHandler(rr, httptest.NewRequest("GET", "/polls/2/chart.svg", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown poll, got %d", rr.Code)
+	}
+}
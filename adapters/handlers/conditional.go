@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"polling-system/domain"
+)
+
+// closedResultsMaxAge is how long caches may reuse the results of a poll
+// that no longer accepts votes.
+const closedResultsMaxAge = 24 * time.Hour
+
+// setResultsValidators sets the caching headers of a results response and
+// reports whether the client's copy is still current, in which case a 304
+// has been written and the body must be skipped.
+func setResultsValidators(w http.ResponseWriter, r *http.Request, result domain.PollResult) bool {
+	// Weak, since the body also carries CapturedAt: equal tags promise
+	// equal counts, not identical bytes. A poll closing on schedule keeps
+	// its version, so the closed state is part of the tag.
+	open := result.Poll.Open(time.Now())
+	etag := fmt.Sprintf(`W/"%d"`, result.Version)
+	modified := result.UpdatedAt
+	if !open {
+		etag = fmt.Sprintf(`W/"%d-closed"`, result.Version)
+		if c := result.Poll.ClosesAt; c != nil && c.After(modified) {
+			modified = *c
+		}
+	}
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if open {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(closedResultsMaxAge.Seconds())))
+	}
+
+	if !notModified(r, etag, modified) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when no entity
+// tags were sent (RFC 9110, section 13.2.2).
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
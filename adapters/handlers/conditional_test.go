@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestResultsConditionalGet(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/results/1", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ResultsHandler(rr, req)
+		return rr
+	}
+
+	first := get("", "")
+	etag := first.Header().Get("ETag")
+	lastModified := first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || etag != `W/"1"` {
+		t.Fatalf("Expected 200 with ETag W/\"1\", got %d %q", first.Code, etag)
+	}
+	if lastModified == "" {
+		t.Error("Expected a Last-Modified header")
+	}
+	if cc := first.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Expected no-cache for an open poll, got %q", cc)
+	}
+
+	tests := []struct {
+		name, header, value string
+		want                int
+	}{
+		{"matching etag", "If-None-Match", etag, http.StatusNotModified},
+		{"strong form of the etag", "If-None-Match", `"1"`, http.StatusNotModified},
+		{"one of several etags", "If-None-Match", `W/"0", W/"1"`, http.StatusNotModified},
+		{"stale etag", "If-None-Match", `W/"0"`, http.StatusOK},
+		{"not modified since", "If-Modified-Since", lastModified, http.StatusNotModified},
+		{"modified since", "If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), http.StatusOK},
+	}
+	for _, tt := range tests {
+		rr := get(tt.header, tt.value)
+		if rr.Code != tt.want {
+			t.Errorf("%s: got status %d want %d", tt.name, rr.Code, tt.want)
+		}
+		if tt.want == http.StatusNotModified && rr.Body.Len() != 0 {
+			t.Errorf("%s: expected an empty body, got %q", tt.name, rr.Body.String())
+		}
+	}
+
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+	if rr := get("If-None-Match", etag); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `W/"2"` {
+		t.Errorf("Expected 200 with ETag W/\"2\" after a new vote, got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+
+	etag = get("", "").Header().Get("ETag")
+	_ = mockService.ClosePoll(ctx, "1")
+	if rr := get("If-None-Match", etag); rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag {
+		t.Errorf("Expected 200 with a new ETag once the poll closed, got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+}
+
+func TestResultsConditionalGetClosesOnSchedule(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	// Far enough ahead that Last-Modified moves on by a whole second
+	closesAt := time.Now().Add(1500 * time.Millisecond)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}, ClosesAt: &closesAt})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	open := httptest.NewRecorder()
+	handler.ResultsHandler(open, httptest.NewRequest("GET", "/results/1", nil))
+	time.Sleep(time.Until(closesAt))
+
+	for _, header := range []string{"If-None-Match", "If-Modified-Since"} {
+		value := open.Header().Get("ETag")
+		if header == "If-Modified-Since" {
+			value = open.Header().Get("Last-Modified")
+		}
+		req := httptest.NewRequest("GET", "/results/1", nil)
+		req.Header.Set(header, value)
+		rr := httptest.NewRecorder()
+		handler.ResultsHandler(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 once the poll closed without a new vote, got %d", header, rr.Code)
+		}
+	}
+}
+
+func TestResultsCacheControlClosedPoll(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	closed := time.Now().Add(-time.Minute)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}, ClosesAt: &closed})
+
+	rr := httptest.NewRecorder()
+	handler.ResultsHandler(rr, httptest.NewRequest("GET", "/results/1", nil))
+
+	if cc := rr.Header().Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("Expected closed poll results to be cacheable, got %q", cc)
+	}
+	if lm := rr.Header().Get("Last-Modified"); lm != closed.UTC().Format(http.TimeFormat) {
+		t.Errorf("Expected Last-Modified to be the closing time, got %q", lm)
+	}
+}
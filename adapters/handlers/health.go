@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness, readiness and build information
+// endpoints used by the container orchestrator.
+type HealthHandler struct {
+	started time.Time
+	checks  []readinessCheck
+}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{started: time.Now()}
+}
+
+// AddCheck registers a readiness check. /readyz fails while any check
+// returns an error.
+func (h *HealthHandler) AddCheck(name string, check func(ctx context.Context) error) {
+	h.checks = append(h.checks, readinessCheck{name: name, check: check})
+}
+
+type readinessReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type versionInfo struct {
+	Module        string `json:"module"`
+	Version       string `json:"version"`
+	GoVersion     string `json:"go_version"`
+	Revision      string `json:"vcs_revision,omitempty"`
+	CommitTime    string `json:"vcs_time,omitempty"`
+	Modified      bool   `json:"vcs_modified,omitempty"`
+	StartedAt     string `json:"started_at"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Uptime        string `json:"uptime"`
+}
+
+// Healthz reports that the process is alive and serving HTTP.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// Readyz runs every readiness check and answers 503 if any of them fails.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := readinessReport{Status: "ready", Checks: make(map[string]string, len(h.checks))}
+	status := http.StatusOK
+	for _, c := range h.checks {
+		if err := c.check(r.Context()); err != nil {
+			report.Checks[c.name] = err.Error()
+			report.Status = "not ready"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		report.Checks[c.name] = "ok"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// Version reports the module version, VCS revision and uptime.
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	uptime := time.Since(h.started)
+	info := versionInfo{
+		Version:       "unknown",
+		StartedAt:     h.started.UTC().Format(time.RFC3339),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Uptime:        uptime.Round(time.Second).String(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Module = bi.Main.Path
+		info.Version = bi.Main.Version
+		info.GoVersion = bi.GoVersion
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.time":
+				info.CommitTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(info)
+}
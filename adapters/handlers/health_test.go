@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthz(t *testing.T) {
+	health := NewHealthHandler()
+
+	rr := httptest.NewRecorder()
+	health.Healthz(rr, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "ok\n" {
+		t.Errorf("Unexpected response: %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	health := NewHealthHandler()
+	draining := false
+	health.AddCheck("repository", func(ctx context.Context) error { return nil })
+	health.AddCheck("draining", func(ctx context.Context) error {
+		if draining {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	health.Readyz(rr, httptest.NewRequest("GET", "/readyz", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected ready, got status %d: %s", rr.Code, rr.Body.String())
+	}
+
+	draining = true
+	rr = httptest.NewRecorder()
+	health.Readyz(rr, httptest.NewRequest("GET", "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected not ready while draining, got status %d", rr.Code)
+	}
+
+	var report readinessReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Checks["repository"] != "ok" || report.Checks["draining"] != "server is shutting down" {
+		t.Errorf("Unexpected readiness report: %+v", report)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	health := NewHealthHandler()
+
+	rr := httptest.NewRecorder()
+	health.Version(rr, httptest.NewRequest("GET", "/version", nil))
+
+	var info versionInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.GoVersion == "" || info.StartedAt == "" || info.Uptime == "" {
+		t.Errorf("Expected build and uptime information, got %+v", info)
+	}
+}
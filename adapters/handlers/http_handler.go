@@ -1,22 +1,80 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+
 	"polling-system/domain"
 	"polling-system/ports"
 )
 
+type Config struct {
+	// UpdateInterval is the shortest time between two results events of a
+	// live update stream; votes in between are sent together.
+	UpdateInterval time.Duration
+	// HeartbeatInterval is how often idle live update streams send a comment
+	// to keep the connection open.
+	HeartbeatInterval time.Duration
+	// RetryDelay is how long live update clients wait before reconnecting.
+	RetryDelay time.Duration
+	// ResyncInterval is how often delta streams send the full tally.
+	ResyncInterval time.Duration
+	// MaxWait caps how long a long-polling results request may block.
+	MaxWait time.Duration
+	// MaxBodyBytes caps the size of JSON request bodies.
+	MaxBodyBytes int64
+	// MaxVotesPerRequest caps the number of votes in one /vote_multiple call.
+	MaxVotesPerRequest int
+	// MaxPollsPerStream caps the number of polls one /poll_updates stream
+	// may watch.
+	MaxPollsPerStream int
+	// PublicURL is the server's base URL without a trailing slash, used for
+	// the absolute links of poll pages; when empty it is taken from the
+	// request.
+	PublicURL string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		UpdateInterval:     3 * time.Second,
+		HeartbeatInterval:  15 * time.Second,
+		RetryDelay:         3 * time.Second,
+		ResyncInterval:     30 * time.Second,
+		MaxWait:            time.Minute,
+		MaxBodyBytes:       1 << 20,
+		MaxVotesPerRequest: 100,
+		MaxPollsPerStream:  50,
+	}
+}
+
 type HTTPHandler struct {
-	pollService ports.PollService
+	pollService  ports.PollService
+	cfg          Config
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewHTTPHandler(pollService ports.PollService, cfg Config) *HTTPHandler {
+	return &HTTPHandler{
+		pollService: pollService,
+		cfg:         cfg,
+		shutdown:    make(chan struct{}),
+	}
 }
 
-func NewHTTPHandler(pollService ports.PollService) *HTTPHandler {
-	return &HTTPHandler{pollService: pollService}
+// Shutdown ends every open live-update stream with a final shutdown event so
+// the server can finish draining connections.
+func (h *HTTPHandler) Shutdown() {
+	h.shutdownOnce.Do(func() { close(h.shutdown) })
 }
 
 func (h *HTTPHandler) CreatePollHandler(w http.ResponseWriter, r *http.Request) {
@@ -26,15 +84,15 @@ func (h *HTTPHandler) CreatePollHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	var poll domain.Poll
-	err := json.NewDecoder(r.Body).Decode(&poll)
+	err := h.decodeJSON(w, r, &poll)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = h.pollService.CreatePoll(poll)
+	err = h.pollService.CreatePoll(r.Context(), poll)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
@@ -49,7 +107,7 @@ func (h *HTTPHandler) VoteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var vote domain.Vote
-	err := json.NewDecoder(r.Body).Decode(&vote)
+	err := h.decodeJSON(w, r, &vote)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -61,13 +119,15 @@ func (h *HTTPHandler) VoteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.pollService.Vote(vote)
+	ballot, err := h.pollService.Vote(r.Context(), vote)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ballot)
 }
 
 func (h *HTTPHandler) VoteMultipleHandler(w http.ResponseWriter, r *http.Request) {
@@ -77,21 +137,27 @@ func (h *HTTPHandler) VoteMultipleHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	var votes []domain.Vote
-	fmt.Println("Body=", r.Body)
-	err := json.NewDecoder(r.Body).Decode(&votes)
+	err := h.decodeJSON(w, r, &votes)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if h.cfg.MaxVotesPerRequest > 0 && len(votes) > h.cfg.MaxVotesPerRequest {
+		http.Error(w, fmt.Sprintf("Too many votes, at most %d per request", h.cfg.MaxVotesPerRequest), http.StatusRequestEntityTooLarge)
+		return
+	}
+
 	multiVote := domain.MultiVote{Votes: votes}
-	err = h.pollService.VoteMultiple(multiVote)
+	ballots, err := h.pollService.VoteMultiple(r.Context(), multiVote)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ballots)
 }
 
 func (h *HTTPHandler) ResultsHandler(w http.ResponseWriter, r *http.Request) {
@@ -100,17 +166,27 @@ func (h *HTTPHandler) ResultsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract poll ID from URL
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
 		http.Error(w, "Invalid URL", http.StatusBadRequest)
 		return
 	}
-	pollID := parts[2]
 
-	results, err := h.pollService.GetResults(pollID)
+	var results domain.PollResult
+	var err error
+	switch query := r.URL.Query(); {
+	case query.Has("wait"):
+		results, err = h.waitForResults(w, r, pollID)
+	case query.Has("at"):
+		results, err = h.resultsAt(r, pollID)
+	default:
+		results, err = h.pollService.GetResults(r.Context(), pollID)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	if setResultsValidators(w, r, results) {
 		return
 	}
 
@@ -118,35 +194,310 @@ func (h *HTTPHandler) ResultsHandler(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(results)
 }
 
+var errBadLongPoll = errors.New("wait must be a positive duration and since a tally version")
+
+// waitForResults serves /results/{id}?wait=30s&since=<version>: it returns
+// as soon as the tally version differs from since, or with the current
+// results once the wait is over. since defaults to the current version.
+func (h *HTTPHandler) waitForResults(w http.ResponseWriter, r *http.Request, pollID string) (domain.PollResult, error) {
+	query := r.URL.Query()
+	wait, err := time.ParseDuration(query.Get("wait"))
+	if err != nil || wait <= 0 {
+		return domain.PollResult{}, errBadLongPoll
+	}
+	wait = min(wait, h.cfg.MaxWait)
+
+	// The wait may outlast the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var since *uint64
+	if s := query.Get("since"); s != "" {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return domain.PollResult{}, errBadLongPoll
+		}
+		since = &v
+	}
+
+	for {
+		changed, err := h.pollService.ResultsChanged(r.Context(), pollID)
+		if err != nil {
+			return domain.PollResult{}, err
+		}
+		result, err := h.pollService.GetResults(r.Context(), pollID)
+		if err != nil {
+			return domain.PollResult{}, err
+		}
+		if since == nil {
+			since = &result.Version
+		}
+		if result.Version != *since {
+			return result, nil
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return result, nil
+		case <-h.shutdown:
+			return result, nil
+		case <-r.Context().Done():
+			return domain.PollResult{}, r.Context().Err()
+		}
+	}
+}
+
+// PollUpdatesHandler streams a results event whenever the tally changes,
+// at most once per UpdateInterval, each with the tally version as its ID.
+// A client reconnecting with Last-Event-ID only gets results newer than
+// that; since every event carries the full tally, nothing is missed.
+//
+// With ?mode=delta, changes are sent as delta events holding only the
+// options whose counts changed, and a full results event follows every
+// ResyncInterval so clients that missed something catch up.
 func (h *HTTPHandler) PollUpdatesHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract poll ID from URL
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
 		http.Error(w, "Invalid URL", http.StatusBadRequest)
 		return
 	}
-	pollID := parts[2]
-
-	//pollID := r.PathValue("id")
-	if pollID == "" {
-		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+	var delta bool
+	switch r.URL.Query().Get("mode") {
+	case "", "full":
+	case "delta":
+		delta = true
+	default:
+		http.Error(w, `mode must be "full" or "delta"`, http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+	ctx := r.Context()
+	sse := newSSEWriter(w)
+	lastSent, resumed := lastEventID(r)
 
-	for {
-		result, err := h.pollService.GetResults(pollID)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	// The stream outlives the server's write timeout by design
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	heartbeat := time.NewTicker(h.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	var closes <-chan time.Time
+	// base is the tally the client has; lastFull is when it last got all of it
+	var base domain.PollResult
+	var lastFull time.Time
+
+	for started := false; ; started = true {
+		changed, err := h.pollService.ResultsChanged(ctx, pollID)
+		var result domain.PollResult
+		if err == nil {
+			result, err = h.pollService.GetResults(ctx, pollID)
+		}
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			// The client is gone; nobody is left to read an error
+			return
+		case !started:
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		case errors.Is(err, domain.ErrPollNotFound):
+			_ = sse.event(eventDeleted, "", map[string]string{"poll_id": pollID})
+			return
+		default:
+			return
+		}
+
+		open := result.Poll.Open(time.Now())
+		id := strconv.FormatUint(result.Version, 10)
+		if !started {
+			if !open && resumed && result.Version == lastSent {
+				// 204 tells EventSource clients to stop reconnecting
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if sse.retry(h.cfg.RetryDelay) != nil {
+				return
+			}
+			if resumed && result.Version == lastSent {
+				base, lastFull = result, time.Now()
+			}
+		}
+		if !open {
+			_ = sse.event(eventClosed, id, result)
+			return
+		}
+		if !resumed || result.Version != lastSent {
+			if delta && !lastFull.IsZero() && time.Since(lastFull) < h.cfg.ResyncInterval {
+				err = sse.event(eventDelta, id, newResultsDelta(base, result))
+			} else {
+				err = sse.event(eventResults, id, result)
+				lastFull = time.Now()
+			}
+			if err != nil {
+				return
+			}
+			base = result
+			lastSent, resumed = result.Version, true
+
+			// Later changes are picked up once the interval has passed
+			select {
+			case <-time.After(h.cfg.UpdateInterval):
+				continue
+			case <-ctx.Done():
+				return
+			case <-h.shutdown:
+				h.endStream(sse)
+				return
+			}
+		}
+
+		if closes == nil && result.Poll.ClosesAt != nil {
+			closes = time.After(time.Until(*result.Poll.ClosesAt))
+		}
+		select {
+		case <-changed:
+		case <-closes:
+		case <-heartbeat.C:
+			if sse.comment("heartbeat") != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-h.shutdown:
+			h.endStream(sse)
 			return
 		}
+	}
+}
+
+func (h *HTTPHandler) endStream(sse sseWriter) {
+	_ = sse.event(eventShutdown, "", map[string]string{"message": "server is shutting down"})
+}
+
+func (h *HTTPHandler) TallyCommitmentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-		fmt.Fprintf(w, "data: %v\n\n", result.Results)
-		w.(http.Flusher).Flush()
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	tally, err := h.pollService.TallyCommitment(r.Context(), pollID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tally)
+}
+
+func (h *HTTPHandler) VerifyReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	var receipt domain.Receipt
+	err := h.decodeJSON(w, r, &receipt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if receipt.PollID != pollID {
+		http.Error(w, "Receipt belongs to a different poll", http.StatusBadRequest)
+		return
+	}
+
+	verification, err := h.pollService.VerifyReceipt(r.Context(), receipt)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
 
-		time.Sleep(3 * time.Second)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(verification)
+}
+
+// AuditHandler streams the poll's hash-chained audit log as newline-delimited
+// JSON so it can be saved and checked offline with cmd/auditverify.
+func (h *HTTPHandler) AuditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.pollService.AuditLog(r.Context(), pollID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "poll-"+pollID+"-audit.ndjson"))
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		_ = enc.Encode(entry)
+	}
+}
+
+func (h *HTTPHandler) decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	_, span := otel.Tracer("polling-system/adapters/handlers").Start(r.Context(), "decode request body")
+	defer span.End()
+
+	if h.cfg.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxBodyBytes)
+	}
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// errorStatus maps service errors to a response status. Requests that ran
+// out of time or were abandoned report 503 so clients know a retry may work.
+func errorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, domain.ErrPollNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, domain.ErrNotBallotOwner) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, domain.ErrPollClosed) || errors.Is(err, domain.ErrPollExists) || errors.Is(err, domain.ErrNoVote) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, domain.ErrInvalidOption) || errors.Is(err, domain.ErrInvalidPoll) || errors.Is(err, errBadLongPoll) || errors.Is(err, errBadTimeTravel) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, errors.ErrUnsupported) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// pollIDFromPath extracts the poll ID from paths shaped like /results/{id} or
+// /polls/{id}/... so handlers work both behind the mux and when called directly.
+func pollIDFromPath(r *http.Request) (string, bool) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 || parts[2] == "" {
+		return "", false
 	}
+	return parts[2], true
 }
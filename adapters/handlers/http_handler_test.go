@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"polling-system/domain"
 	"polling-system/mocks"
@@ -13,7 +18,7 @@ import (
 
 func TestCreatePollHandler(t *testing.T) {
 	mockService := mocks.NewMockPollService()
-	handler := NewHTTPHandler(mockService)
+	handler := NewHTTPHandler(mockService, DefaultConfig())
 
 	poll := domain.Poll{
 		ID:       "1",
@@ -46,11 +51,12 @@ func TestCreatePollHandler(t *testing.T) {
 }
 
 func TestVoteHandler(t *testing.T) {
+	ctx := context.Background()
 	mockService := mocks.NewMockPollService()
-	handler := NewHTTPHandler(mockService)
+	handler := NewHTTPHandler(mockService, DefaultConfig())
 
 	// Create a poll first
-	_ = mockService.CreatePoll(domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
 
 	vote := domain.Vote{
 		PollID: "1",
@@ -68,15 +74,80 @@ func TestVoteHandler(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v",
 			status, http.StatusOK)
 	}
+
+	var ballot domain.Ballot
+	if err := json.Unmarshal(rr.Body.Bytes(), &ballot); err != nil {
+		t.Fatal(err)
+	}
+	if ballot.Receipt.PollID != "1" {
+		t.Errorf("handler returned unexpected ballot: %+v", ballot)
+	}
+}
+
+func TestVoteHandlerCancelled(t *testing.T) {
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(context.Background(), domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	body, _ := json.Marshal(domain.Vote{PollID: "1", Option: "Option 1"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("POST", "/vote", bytes.NewBuffer(body)).WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	handler.VoteHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestVoteHandlerErrors(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	tests := []struct {
+		vote   domain.Vote
+		status int
+	}{
+		{domain.Vote{PollID: "2", Option: "Option 1"}, http.StatusNotFound},
+		{domain.Vote{PollID: "1", Option: "Option 3"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(tt.vote)
+		rr := httptest.NewRecorder()
+		handler.VoteHandler(rr, httptest.NewRequest("POST", "/vote", bytes.NewBuffer(body)))
+		if rr.Code != tt.status {
+			t.Errorf("%+v: expected status %d, got %d", tt.vote, tt.status, rr.Code)
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	handler := WithTimeout(time.Second, func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/results/1", nil))
+
+	if !ok || time.Until(deadline) > time.Second {
+		t.Errorf("Expected a deadline within a second, got %v (set=%v)", deadline, ok)
+	}
 }
 
 func TestVoteMultipleHandler(t *testing.T) {
+	ctx := context.Background()
 	mockService := mocks.NewMockPollService()
-	handler := NewHTTPHandler(mockService)
+	handler := NewHTTPHandler(mockService, DefaultConfig())
 
 	// Create test polls
-	_ = mockService.CreatePoll(domain.Poll{ID: "1", Question: "Test 1?", Options: []string{"Option 1", "Option 2"}})
-	_ = mockService.CreatePoll(domain.Poll{ID: "2", Question: "Test 2?", Options: []string{"Option A", "Option B"}})
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test 1?", Options: []string{"Option 1", "Option 2"}})
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "2", Question: "Test 2?", Options: []string{"Option A", "Option B"}})
 
 	votes := []domain.Vote{
 		{PollID: "1", Option: "Option 1"},
@@ -95,8 +166,8 @@ func TestVoteMultipleHandler(t *testing.T) {
 	}
 
 	// Verify votes were recorded
-	result1, _ := mockService.GetResults("1")
-	result2, _ := mockService.GetResults("2")
+	result1, _ := mockService.GetResults(ctx, "1")
+	result2, _ := mockService.GetResults(ctx, "2")
 
 	if result1.Results["Option 1"] != 1 {
 		t.Errorf("Expected 1 vote for Option 1 in poll 1, got %d", result1.Results["Option 1"])
@@ -107,9 +178,40 @@ func TestVoteMultipleHandler(t *testing.T) {
 	}
 }
 
+func TestVoteMultipleHandlerLimit(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	cfg := DefaultConfig()
+	cfg.MaxVotesPerRequest = 1
+	handler := NewHTTPHandler(mockService, cfg)
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test 1?", Options: []string{"Option 1", "Option 2"}})
+
+	votes := []domain.Vote{
+		{PollID: "1", Option: "Option 1"},
+		{PollID: "1", Option: "Option 2"},
+	}
+
+	body, _ := json.Marshal(votes)
+	req := httptest.NewRequest("POST", "/vote_multiple", bytes.NewBuffer(body))
+
+	rr := httptest.NewRecorder()
+	handler.VoteMultipleHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusRequestEntityTooLarge {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusRequestEntityTooLarge)
+	}
+
+	result, _ := mockService.GetResults(ctx, "1")
+	if len(result.Results) != 0 {
+		t.Errorf("Expected no votes to be recorded, got %v", result.Results)
+	}
+}
+
 func TestResultsHandler(t *testing.T) {
+	ctx := context.Background()
 	mockService := mocks.NewMockPollService()
-	handler := NewHTTPHandler(mockService)
+	handler := NewHTTPHandler(mockService, DefaultConfig())
 
 	// Create a test poll and add some votes
 	poll := domain.Poll{
@@ -117,9 +219,9 @@ func TestResultsHandler(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	_ = mockService.CreatePoll(poll)
-	_ = mockService.Vote(domain.Vote{PollID: "1", Option: "Option 1"})
-	_ = mockService.Vote(domain.Vote{PollID: "1", Option: "Option 2"})
+	_ = mockService.CreatePoll(ctx, poll)
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
 
 	req := httptest.NewRequest("GET", "/results/1", nil)
 
@@ -147,10 +249,175 @@ func TestResultsHandler(t *testing.T) {
 	}
 }
 
+func TestResultsLongPoll(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.ResultsHandler(rr, httptest.NewRequest("GET", "/results/1?wait=10s&since=0", nil))
+		done <- rr
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected the long poll to wait for a vote")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	select {
+	case rr := <-done:
+		var result domain.PollResult
+		_ = json.Unmarshal(rr.Body.Bytes(), &result)
+		if rr.Code != http.StatusOK || result.Version != 1 {
+			t.Errorf("Expected 200 with version 1, got %d %s", rr.Code, rr.Body.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the long poll to return after the vote")
+	}
+
+	// A client that is behind gets the current results right away
+	rr := httptest.NewRecorder()
+	handler.ResultsHandler(rr, httptest.NewRequest("GET", "/results/1?wait=10s&since=0", nil))
+	if !strings.Contains(rr.Body.String(), `"Version":1`) {
+		t.Errorf("Expected version 1 without waiting, got %s", rr.Body.String())
+	}
+}
+
+func TestResultsLongPollTimeout(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"wait=20ms", http.StatusOK},
+		{"wait=20ms&since=0", http.StatusOK},
+		{"wait=soon", http.StatusBadRequest},
+		{"wait=-1s", http.StatusBadRequest},
+		{"wait=1s&since=latest", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ResultsHandler(rr, httptest.NewRequest("GET", "/results/1?"+tt.query, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s: got status %d want %d", tt.query, rr.Code, tt.want)
+		}
+	}
+}
+
+func TestVerifyReceiptHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	ballot, _ := mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	body, _ := json.Marshal(ballot.Receipt)
+	req := httptest.NewRequest("POST", "/polls/1/receipts/verify", bytes.NewBuffer(body))
+
+	rr := httptest.NewRecorder()
+	handler.VerifyReceiptHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var verification domain.ReceiptVerification
+	if err := json.Unmarshal(rr.Body.Bytes(), &verification); err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Valid {
+		t.Errorf("handler reported receipt as invalid: %+v", verification)
+	}
+
+	// A receipt submitted against another poll's URL is rejected outright
+	req = httptest.NewRequest("POST", "/polls/2/receipts/verify", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+	handler.VerifyReceiptHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestAuditHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	req := httptest.NewRequest("GET", "/polls/1/audit", nil)
+
+	rr := httptest.NewRecorder()
+	handler.AuditHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var entries []domain.AuditEntry
+	dec := json.NewDecoder(rr.Body)
+	for dec.More() {
+		var entry domain.AuditEntry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if _, err := domain.VerifyAuditLog("1", entries); err != nil || len(entries) != 2 {
+		t.Errorf("handler returned an invalid audit log (%d entries): %v", len(entries), err)
+	}
+}
+
+func TestPollUpdatesHandlerShutdown(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	req := httptest.NewRequest("GET", "/poll_updates/1", nil)
+	rr := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler.PollUpdatesHandler(rr, req)
+		close(done)
+	}()
+
+	handler.Shutdown()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for stream to end after shutdown")
+	}
+
+	if !strings.HasSuffix(rr.Body.String(), "event: shutdown\ndata: {\"message\":\"server is shutting down\"}\n\n") {
+		t.Errorf("stream did not end with a shutdown event: %q", rr.Body.String())
+	}
+}
+
 /*
 func TestPollUpdatesHandler(t *testing.T) {
+	ctx := context.Background()
 	mockService := mocks.NewMockPollService()
-	handler := NewHTTPHandler(mockService)
+	handler := NewHTTPHandler(mockService, DefaultConfig())
 
 	// Create a test poll
 	poll := domain.Poll{
@@ -158,7 +425,7 @@ func TestPollUpdatesHandler(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	mockService.CreatePoll(poll)
+	mockService.CreatePoll(ctx, poll)
 
 	req := httptest.NewRequest("GET", "/poll_updates/1", nil)
 
@@ -172,7 +439,7 @@ func TestPollUpdatesHandler(t *testing.T) {
 	}()
 
 	// Add a vote to trigger an update
-	mockService.Vote(domain.Vote{PollID: "1", Option: "Option 1"})
+	mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
 
 	// Wait for the handler to write the update or timeout
 	select {
@@ -194,3 +461,52 @@ func TestPollUpdatesHandler(t *testing.T) {
 }
 
 */
+
+func TestUnknownPoll(t *testing.T) {
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	tests := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/results/missing", handler.ResultsHandler},
+		{"/polls/missing", handler.PollPageHandler},
+		{"/polls/missing/chart.svg", handler.ChartHandler},
+		{"/polls/missing/card.png", handler.CardHandler},
+		{"/polls/missing/qr.svg", handler.QRHandler},
+		{"/polls/missing/qr.png", handler.QRHandler},
+		{"/polls/missing/history", handler.TallyHistoryHandler},
+		{"/polls/missing/events", handler.PollEventsHandler},
+		{"/polls/missing/commitment", handler.TallyCommitmentHandler},
+		{"/polls/missing/audit", handler.AuditHandler},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		tt.handler(rr, httptest.NewRequest("GET", tt.path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", tt.path, rr.Code)
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("poll not found: %w", domain.ErrPollNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w \"C\"", domain.ErrInvalidOption), http.StatusBadRequest},
+		{fmt.Errorf("%w: poll id is required", domain.ErrInvalidPoll), http.StatusBadRequest},
+		{domain.ErrPollClosed, http.StatusConflict},
+		{fmt.Errorf("%w: voter \"alice\"", domain.ErrNotBallotOwner), http.StatusForbidden},
+		{domain.ErrNoHistory, http.StatusNotImplemented},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable},
+		{errors.New("disk full"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"polling-system/domain"
+)
+
+var errBadTimeTravel = errors.New("at must be an RFC 3339 time or a duration since the poll was created")
+
+// resultsAt serves /results/{id}?at=...: the tally as it stood at an RFC
+// 3339 time, or a duration like 5m after the poll was created.
+func (h *HTTPHandler) resultsAt(r *http.Request, pollID string) (domain.PollResult, error) {
+	value := r.URL.Query().Get("at")
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		offset, durErr := time.ParseDuration(value)
+		if durErr != nil || offset < 0 {
+			return domain.PollResult{}, errBadTimeTravel
+		}
+		current, err := h.pollService.GetResults(r.Context(), pollID)
+		if err != nil {
+			return domain.PollResult{}, err
+		}
+		if current.Poll.CreatedAt == nil {
+			return domain.PollResult{}, fmt.Errorf("poll %s has no creation time: %w", pollID, errors.ErrUnsupported)
+		}
+		at = current.Poll.CreatedAt.Add(offset)
+	}
+	return h.pollService.ResultsAt(r.Context(), pollID, at)
+}
+
+func (h *HTTPHandler) ClosePollHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pollService.ClosePoll(r.Context(), pollID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *HTTPHandler) RetractVoteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	// The nonce comes from the voter's ballot; nobody else has it
+	var retraction struct {
+		VoterID string `json:"voter_id"`
+		Nonce   string `json:"nonce"`
+	}
+	if err := h.decodeJSON(w, r, &retraction); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if retraction.VoterID == "" {
+		http.Error(w, "Missing voter_id", http.StatusBadRequest)
+		return
+	}
+	if retraction.Nonce == "" {
+		http.Error(w, "Missing nonce", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pollService.RetractVote(r.Context(), pollID, retraction.VoterID, retraction.Nonce); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PollEventsHandler streams a poll's events as NDJSON, oldest first.
+func (h *HTTPHandler) PollEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.pollService.PollEvents(r.Context(), pollID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		_ = enc.Encode(e)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestResultsAt(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	created := time.Now().Add(-10 * time.Minute).UTC()
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}, CreatedAt: &created})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	tests := []struct {
+		at     string
+		status int
+		votes  int
+	}{
+		{"5m", http.StatusOK, 0},
+		{created.Add(5 * time.Minute).Format(time.RFC3339), http.StatusOK, 0},
+		{"20m", http.StatusOK, 1},
+		{"soon", http.StatusBadRequest, 0},
+		{"-5m", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ResultsHandler(rr, httptest.NewRequest("GET", "/results/1?at="+tt.at, nil))
+		if rr.Code != tt.status {
+			t.Errorf("at=%s: expected status %d, got %d", tt.at, tt.status, rr.Code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var result domain.PollResult
+		if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Results["Option 1"] != tt.votes {
+			t.Errorf("at=%s: expected %d votes, got %v", tt.at, tt.votes, result.Results)
+		}
+	}
+}
+
+func TestClosePollHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	rr := httptest.NewRecorder()
+	handler.ClosePollHandler(rr, httptest.NewRequest("POST", "/polls/1/close", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", rr.Code)
+	}
+	if result, _ := mockService.GetResults(ctx, "1"); result.Poll.Open(time.Now()) {
+		t.Error("Expected the poll to be closed")
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ClosePollHandler(rr, httptest.NewRequest("POST", "/polls/1/close", nil))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 closing twice, got %d", rr.Code)
+	}
+}
+
+func TestRetractVoteHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	ballot, _ := mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1", VoterID: "alice"})
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{}`, http.StatusBadRequest},
+		{`{"voter_id": "alice"}`, http.StatusBadRequest},
+		{`{"voter_id": "alice", "nonce": "guess"}`, http.StatusForbidden},
+		{`{"voter_id": "alice", "nonce": "` + ballot.Nonce + `"}`, http.StatusNoContent},
+		{`{"voter_id": "alice", "nonce": "` + ballot.Nonce + `"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.RetractVoteHandler(rr, httptest.NewRequest("POST", "/polls/1/retract", strings.NewReader(tt.body)))
+		if rr.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.status, rr.Code)
+		}
+	}
+	if result, _ := mockService.GetResults(ctx, "1"); result.Results["Option 1"] != 0 {
+		t.Errorf("Expected the vote to be retracted, got %v", result.Results)
+	}
+}
+
+func TestPollEventsHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	rr := httptest.NewRecorder()
+	handler.PollEventsHandler(rr, httptest.NewRequest("GET", "/polls/1/events", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	var events []domain.PollEvent
+	dec := json.NewDecoder(rr.Body)
+	for dec.More() {
+		var e domain.PollEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 || events[0].Type != domain.PollCreated || events[1].Option != "Option 2" {
+		t.Errorf("Expected the creation and the vote, got %+v", events)
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"polling-system/domain"
+)
+
+// MultiPollUpdatesHandler serves /poll_updates?ids=1,2,3: one stream with
+// the events of several polls, each tagged with its poll ID. The event IDs
+// are cursors holding the tally version of every poll and whether it was
+// seen closed, so Last-Event-ID resumes all of them at once. The stream ends when every poll is closed
+// or gone.
+func (h *HTTPHandler) MultiPollUpdatesHandler(w http.ResponseWriter, r *http.Request) {
+	ids := uniquePollIDs(r.URL.Query().Get("ids"))
+	if len(ids) == 0 {
+		http.Error(w, "ids must list at least one poll", http.StatusBadRequest)
+		return
+	}
+	if h.cfg.MaxPollsPerStream > 0 && len(ids) > h.cfg.MaxPollsPerStream {
+		http.Error(w, fmt.Sprintf("Too many polls, at most %d per stream", h.cfg.MaxPollsPerStream), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ctx := r.Context()
+	seen := parseCursor(r.Header.Get("Last-Event-ID"))
+	for pollID := range seen {
+		if !slices.Contains(ids, pollID) {
+			delete(seen, pollID)
+		}
+	}
+
+	finished := true
+	for _, pollID := range ids {
+		result, err := h.pollService.GetResults(ctx, pollID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("poll %s: %v", pollID, err), errorStatus(err))
+			return
+		}
+		if cursor, ok := seen[pollID]; result.Poll.Open(time.Now()) || !ok || !cursor.closed || cursor.version != result.Version {
+			finished = false
+		}
+	}
+	if finished {
+		// 204 tells EventSource clients to stop reconnecting
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sse := newSSEWriter(w)
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if sse.retry(h.cfg.RetryDelay) != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var changes []<-chan struct{}
+		var closesAt time.Time
+		sent := false
+		active := ids[:0]
+
+		for _, pollID := range ids {
+			changed, err := h.pollService.ResultsChanged(ctx, pollID)
+			var result domain.PollResult
+			if err == nil {
+				result, err = h.pollService.GetResults(ctx, pollID)
+			}
+			switch {
+			case err == nil:
+			case ctx.Err() != nil:
+				return
+			case errors.Is(err, domain.ErrPollNotFound):
+				delete(seen, pollID)
+				if sse.event(eventDeleted, seen.String(), map[string]string{"poll_id": pollID}) != nil {
+					return
+				}
+				continue
+			default:
+				return
+			}
+
+			cursor, ok := seen[pollID]
+			if !result.Poll.Open(time.Now()) {
+				// A client that saw the poll close has nothing left to get.
+				// Polls closing on schedule keep their version, so the
+				// cursor tracks the closed state separately.
+				if !ok || !cursor.closed || cursor.version != result.Version {
+					seen[pollID] = pollCursor{version: result.Version, closed: true}
+					if sse.event(eventClosed, seen.String(), result) != nil {
+						return
+					}
+					sent = true
+				}
+				continue
+			}
+			if !ok || cursor.version != result.Version {
+				seen[pollID] = pollCursor{version: result.Version}
+				if sse.event(eventResults, seen.String(), result) != nil {
+					return
+				}
+				sent = true
+			}
+
+			active = append(active, pollID)
+			changes = append(changes, changed)
+			if c := result.Poll.ClosesAt; c != nil && (closesAt.IsZero() || c.Before(closesAt)) {
+				closesAt = *c
+			}
+		}
+		ids = active
+		if len(ids) == 0 {
+			return
+		}
+
+		wait := h.cfg.UpdateInterval
+		if !sent {
+			wait = 0
+		}
+		if !h.waitForAny(ctx, sse, changes, wait, closesAt, heartbeat.C) {
+			return
+		}
+	}
+}
+
+// waitForAny blocks for at least minWait and until one of changes fires or
+// closesAt passes. It reports false once the stream has to end.
+func (h *HTTPHandler) waitForAny(ctx context.Context, sse sseWriter, changes []<-chan struct{}, minWait time.Duration, closesAt time.Time, heartbeat <-chan time.Time) bool {
+	select {
+	case <-time.After(minWait):
+	case <-ctx.Done():
+		return false
+	case <-h.shutdown:
+		h.endStream(sse)
+		return false
+	}
+
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	woken := make(chan struct{}, 1)
+	for _, changed := range changes {
+		go func(changed <-chan struct{}) {
+			select {
+			case <-changed:
+				select {
+				case woken <- struct{}{}:
+				default:
+				}
+			case <-waitCtx.Done():
+			}
+		}(changed)
+	}
+	var closes <-chan time.Time
+	if !closesAt.IsZero() {
+		closes = time.After(time.Until(closesAt))
+	}
+
+	for {
+		select {
+		case <-woken:
+			return true
+		case <-closes:
+			return true
+		case <-heartbeat:
+			if sse.comment("heartbeat") != nil {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		case <-h.shutdown:
+			h.endStream(sse)
+			return false
+		}
+	}
+}
+
+// uniquePollIDs splits a comma-separated list, dropping blanks and repeats.
+func uniquePollIDs(list string) []string {
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// streamCursor maps poll IDs to what a client has seen of them. It is
+// encoded like a query string, e.g. "1=3&2=5c", where a trailing c marks a
+// poll the client saw closed.
+type streamCursor map[string]pollCursor
+
+type pollCursor struct {
+	version uint64
+	closed  bool
+}
+
+func parseCursor(s string) streamCursor {
+	cursor := make(streamCursor)
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return cursor
+	}
+	for pollID := range values {
+		v, closed := strings.CutSuffix(values.Get(pollID), "c")
+		if version, err := strconv.ParseUint(v, 10, 64); err == nil {
+			cursor[pollID] = pollCursor{version: version, closed: closed}
+		}
+	}
+	return cursor
+}
+
+func (c streamCursor) String() string {
+	values := make(url.Values, len(c))
+	for pollID, poll := range c {
+		v := strconv.FormatUint(poll.version, 10)
+		if poll.closed {
+			v += "c"
+		}
+		values.Set(pollID, v)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestMultiPollUpdates(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, streamConfig())
+	server := httptest.NewServer(http.HandlerFunc(handler.MultiPollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	for _, id := range []string{"1", "2"} {
+		_ = mockService.CreatePoll(ctx, domain.Poll{ID: id, Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	}
+
+	_, stream := openStream(t, server.URL+"/poll_updates?ids=1,2,1", "")
+	if ev := readEvent(t, stream); ev.retry == "" {
+		t.Fatalf("Expected a retry hint first, got %+v", ev)
+	}
+	polls := map[string]bool{}
+	var ev sseEvent
+	for i := 0; i < 2; i++ {
+		ev = readEvent(t, stream)
+		var result domain.PollResult
+		_ = json.Unmarshal([]byte(ev.data), &result)
+		polls[result.Poll.ID] = ev.name == eventResults
+	}
+	if !polls["1"] || !polls["2"] {
+		t.Fatalf("Expected initial results for both polls, got %v", polls)
+	}
+	if ev.id != "1=0&2=0" {
+		t.Errorf("Expected the event ID to be a cursor over both polls, got %q", ev.id)
+	}
+
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "2", Option: "Option 1"})
+	ev = readEvent(t, stream)
+	var result domain.PollResult
+	_ = json.Unmarshal([]byte(ev.data), &result)
+	if ev.name != eventResults || result.Poll.ID != "2" || result.Version != 1 || ev.id != "1=0&2=1" {
+		t.Errorf("Expected results for poll 2 only, got %+v", ev)
+	}
+}
+
+func TestMultiPollUpdatesResume(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, streamConfig())
+	server := httptest.NewServer(http.HandlerFunc(handler.MultiPollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	closed := time.Now().Add(-time.Minute)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "2", Question: "Test?", Options: []string{"Option 1", "Option 2"}, ClosesAt: &closed})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	// Poll 1 moved on since the cursor; poll 2 is closed and was seen
+	_, stream := openStream(t, server.URL+"/poll_updates?ids=1,2", "1=0&2=0c&3=7")
+	readEvent(t, stream)
+	if ev := readEvent(t, stream); ev.name != eventResults || ev.id != "1=1&2=0c" {
+		t.Errorf("Expected only the newer results of poll 1, got %+v", ev)
+	}
+
+	resp, _ := openStream(t, server.URL+"/poll_updates?ids=2", "2=0c")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 once every poll is closed and seen, got %d", resp.StatusCode)
+	}
+
+	// A cursor from before the poll closed still gets told it closed
+	_, stream = openStream(t, server.URL+"/poll_updates?ids=2", "2=0")
+	readEvent(t, stream)
+	if ev := readEvent(t, stream); ev.name != eventClosed || ev.id != "2=0c" {
+		t.Errorf("Expected the closed event for poll 2, got %+v", ev)
+	}
+}
+
+func TestMultiPollUpdatesClosesAfterLastVoteSeen(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, streamConfig())
+	server := httptest.NewServer(http.HandlerFunc(handler.MultiPollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	closesAt := time.Now().Add(300 * time.Millisecond)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}, ClosesAt: &closesAt})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	_, stream := openStream(t, server.URL+"/poll_updates?ids=1", "")
+	readEvent(t, stream)
+	if ev := readEvent(t, stream); ev.name != eventResults || ev.id != "1=1" {
+		t.Fatalf("Expected the results after the last vote, got %+v", ev)
+	}
+	// The poll closes on schedule without another vote
+	if ev := readEvent(t, stream); ev.name != eventClosed || ev.id != "1=1c" {
+		t.Errorf("Expected the poll to be reported closed, got %+v", ev)
+	}
+}
+
+func TestMultiPollUpdatesInvalid(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	cfg := DefaultConfig()
+	cfg.MaxPollsPerStream = 2
+	handler := NewHTTPHandler(mockService, cfg)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"ids=,", http.StatusBadRequest},
+		{"ids=1,2,3", http.StatusRequestEntityTooLarge},
+		{"ids=1,missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.MultiPollUpdatesHandler(rr, httptest.NewRequest("GET", "/poll_updates?"+tt.query, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%q: got status %d want %d", tt.query, rr.Code, tt.want)
+		}
+	}
+}
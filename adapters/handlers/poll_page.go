@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"polling-system/adapters/charts"
+)
+
+// pollPage is a poll's shareable page. Its og: and twitter: tags make chat
+// apps and social networks preview links with the card image of the tally.
+var pollPage = template.Must(template.New("poll").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Question}}</title>
+<meta name="description" content="{{.Summary}}">
+<meta property="og:type" content="website">
+<meta property="og:title" content="{{.Question}}">
+<meta property="og:description" content="{{.Summary}}">
+<meta property="og:url" content="{{.URL}}">
+<meta property="og:image" content="{{.Image}}">
+<meta property="og:image:type" content="image/png">
+<meta property="og:image:width" content="{{.Width}}">
+<meta property="og:image:height" content="{{.Height}}">
+<meta property="og:image:alt" content="{{.Summary}}">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:title" content="{{.Question}}">
+<meta name="twitter:description" content="{{.Summary}}">
+<meta name="twitter:image" content="{{.Image}}">
+<style>body{font-family:Helvetica,Arial,sans-serif;max-width:800px;margin:2em auto;padding:0 1em;color:#222}img{max-width:100%;height:auto}</style>
+</head>
+<body>
+<h1>{{.Question}}</h1>
+<img src="{{.Chart}}" alt="{{.Summary}}">
+<p><a href="/ui/vote.html?id={{.ID}}">Vote</a> · <a href="/ui/results.html?id={{.ID}}">Live results</a></p>
+</body>
+</html>
+`))
+
+// PollPageHandler serves /polls/{id}: an HTML page of the current results
+// whose preview tags point at the card of this tally version.
+func (h *HTTPHandler) PollPageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.pollService.GetResults(r.Context(), pollID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	if setResultsValidators(w, r, result) {
+		return
+	}
+
+	// Link previews are cached by image URL, so the version in it makes a
+	// fresh share show the tally as it is now
+	base := h.publicURL(r) + "/polls/" + url.PathEscape(pollID)
+	var page bytes.Buffer
+	err = pollPage.Execute(&page, map[string]any{
+		"ID":       pollID,
+		"Question": result.Poll.Question,
+		"Summary":  charts.Summary(result),
+		"URL":      base,
+		"Image":    fmt.Sprintf("%s/card.png?v=%d", base, result.Version),
+		"Chart":    fmt.Sprintf("%s/chart.svg?v=%d", base, result.Version),
+		"Width":    charts.CardWidth,
+		"Height":   charts.CardHeight,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page.Bytes())
+}
+
+// CardHandler serves /polls/{id}/card.png: the current results as a PNG
+// sized for link previews.
+func (h *HTTPHandler) CardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.pollService.GetResults(r.Context(), pollID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	if setResultsValidators(w, r, result) {
+		return
+	}
+
+	var card bytes.Buffer
+	if err := charts.PNG(&card, result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(card.Bytes())
+}
+
+// publicURL is the configured base URL, or else the one the request came in
+// on as far as a reverse proxy reports it.
+func (h *HTTPHandler) publicURL(r *http.Request) string {
+	if h.cfg.PublicURL != "" {
+		return h.cfg.PublicURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
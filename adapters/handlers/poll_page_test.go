@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestPollPageHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Tea <or> coffee?", Options: []string{"Tea", "Coffee"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Tea"})
+
+	req := httptest.NewRequest("GET", "/polls/1", nil)
+	req.Host = "polls.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rr := httptest.NewRecorder()
+	handler.PollPageHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	page := rr.Body.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="Tea &lt;or&gt; coffee?">`,
+		`<meta property="og:description" content="Tea 100% · Coffee 0% · 1 vote">`,
+		`<meta property="og:image" content="https://polls.example.com/polls/1/card.png?v=1">`,
+		`<meta property="og:image:width" content="1200">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s in\n%s", want, page)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.PublicURL = "https://vote.example.org"
+	rr = httptest.NewRecorder()
+	NewHTTPHandler(mockService, cfg).PollPageHandler(rr, httptest.NewRequest("GET", "/polls/1", nil))
+	if !strings.Contains(rr.Body.String(), `content="https://vote.example.org/polls/1/card.png?v=1"`) {
+		t.Errorf("Expected the configured public URL in\n%s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.PollPageHandler(rr, httptest.NewRequest("GET", "/polls/2", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown poll, got %d", rr.Code)
+	}
+}
+
+func TestCardHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	rr := httptest.NewRecorder()
+	handler.CardHandler(rr, httptest.NewRequest("GET", "/polls/1/card.png", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected a PNG, got status %d and %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(rr.Body)
+	if err != nil {
+		t.Fatalf("Expected a valid PNG, got %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 1200 || b.Dy() != 630 {
+		t.Errorf("Expected a 1200x630 card, got %v", b)
+	}
+}
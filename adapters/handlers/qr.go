@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"path"
+
+	"polling-system/adapters/charts"
+)
+
+// QRHandler serves /polls/{id}/qr.svg and /polls/{id}/qr.png: a QR code of
+// the poll's voting page, so an audience can scan it from a projector
+// instead of typing the ID. ?size= sets the pixel size and ?level= the
+// error correction level.
+func (h *HTTPHandler) QRHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	opts, err := charts.ParseQROptions(query.Get("size"), query.Get("level"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := h.pollService.GetResults(r.Context(), pollID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	render, contentType := charts.QRSVG, "image/svg+xml"
+	if path.Ext(r.URL.Path) == ".png" {
+		render, contentType = charts.QRPNG, "image/png"
+	}
+	var code bytes.Buffer
+	if err := render(&code, h.voteURL(r, pollID), opts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	_, _ = w.Write(code.Bytes())
+}
+
+// voteURL is the absolute address of the poll's voting page in the web UI.
+func (h *HTTPHandler) voteURL(r *http.Request, pollID string) string {
+	return h.publicURL(r) + "/ui/vote.html?id=" + url.QueryEscape(pollID)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestQRHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	cfg := DefaultConfig()
+	cfg.PublicURL = "https://vote.example.org"
+	handler := NewHTTPHandler(mockService, cfg)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "team lunch", Question: "Where?", Options: []string{"Pizza", "Sushi"}})
+
+	rr := httptest.NewRecorder()
+	handler.QRHandler(rr, httptest.NewRequest("GET", "/polls/team%20lunch/qr.svg", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("Expected an SVG content type, got %q", ct)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "<title>https://vote.example.org/ui/vote.html?id=team+lunch</title>") {
+		t.Errorf("Expected a code of the voting page, got\n%s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.QRHandler(rr, httptest.NewRequest("GET", "/polls/team%20lunch/qr.png?size=300&level=H", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Expected a PNG content type, got %q", ct)
+	}
+	img, err := png.Decode(rr.Body)
+	if err != nil {
+		t.Fatalf("Expected a PNG, got %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("Expected a 300x300 image, got %v", b)
+	}
+
+	for _, query := range []string{"?size=0", "?size=big", "?size=100000", "?level=X"} {
+		rr = httptest.NewRecorder()
+		handler.QRHandler(rr, httptest.NewRequest("GET", "/polls/team%20lunch/qr.png"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rr.Code)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	handler.QRHandler(rr, httptest.NewRequest("GET", "/polls/2/qr.svg", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown poll, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.QRHandler(rr, httptest.NewRequest("POST", "/polls/team%20lunch/qr.svg", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", rr.Code)
+	}
+}
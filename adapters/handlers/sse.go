@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"polling-system/domain"
+)
+
+// Event types of the live update stream.
+const (
+	eventResults  = "results"
+	eventDelta    = "delta"
+	eventClosed   = "closed"
+	eventDeleted  = "deleted"
+	eventShutdown = "shutdown"
+)
+
+// sseWriter writes Server-Sent Events (HTML Living Standard, section
+// 9.2) and flushes each one so it reaches the client right away.
+type sseWriter struct {
+	w http.ResponseWriter
+}
+
+func newSSEWriter(w http.ResponseWriter) sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	return sseWriter{w: w}
+}
+
+// event sends payload as a JSON-encoded event. An empty id leaves the
+// client's last event ID unchanged.
+func (s sseWriter) event(name, id string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", name, data)
+	return s.write(b.String())
+}
+
+// comment keeps idle connections from being closed by proxies; clients
+// ignore it.
+func (s sseWriter) comment(text string) error {
+	return s.write(": " + text + "\n\n")
+}
+
+// retry tells the client how long to wait before reconnecting.
+func (s sseWriter) retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+func (s sseWriter) write(text string) error {
+	if _, err := fmt.Fprint(s.w, text); err != nil {
+		return err
+	}
+	return http.NewResponseController(s.w).Flush()
+}
+
+// lastEventID returns the tally version a reconnecting client last saw.
+func lastEventID(r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	return id, err == nil
+}
+
+// resultsDelta holds the counts that changed between the tally versions
+// Since and Version. Clients apply it only on top of version Since.
+type resultsDelta struct {
+	PollID  string         `json:"poll_id"`
+	Since   uint64         `json:"since"`
+	Version uint64         `json:"version"`
+	Results map[string]int `json:"results"`
+}
+
+func newResultsDelta(base, next domain.PollResult) resultsDelta {
+	delta := resultsDelta{
+		PollID:  next.Poll.ID,
+		Since:   base.Version,
+		Version: next.Version,
+		Results: make(map[string]int),
+	}
+	for option, count := range next.Results {
+		if base.Results[option] != count {
+			delta.Results[option] = count
+		}
+	}
+	for option := range base.Results {
+		if _, ok := next.Results[option]; !ok {
+			delta.Results[option] = 0
+		}
+	}
+	return delta
+}
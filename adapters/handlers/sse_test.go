@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+	"polling-system/ports"
+)
+
+type sseEvent struct {
+	id, name, data, comment, retry string
+}
+
+// readEvent reads up to the next blank line of the stream.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended early: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return ev
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			ev.id = value
+		case "event":
+			ev.name = value
+		case "data":
+			ev.data = value
+		case "retry":
+			ev.retry = value
+		case "":
+			ev.comment = value
+		}
+	}
+}
+
+func streamConfig() Config {
+	cfg := DefaultConfig()
+	cfg.UpdateInterval = 10 * time.Millisecond
+	return cfg
+}
+
+func openStream(t *testing.T, url, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestPollUpdatesEvents(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, streamConfig())
+	server := httptest.NewServer(http.HandlerFunc(handler.PollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	resp, stream := openStream(t, server.URL+"/poll_updates/1", "")
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", ct)
+	}
+	if ev := readEvent(t, stream); ev.retry != "3000" {
+		t.Errorf("Expected a retry hint first, got %+v", ev)
+	}
+	if ev := readEvent(t, stream); ev.name != eventResults || ev.id != "0" {
+		t.Errorf("Expected results event 0, got %+v", ev)
+	}
+
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	// Both votes may arrive together, but the IDs never go back
+	var result domain.PollResult
+	for result.Version < 2 {
+		ev := readEvent(t, stream)
+		if ev.name != eventResults {
+			t.Fatalf("Expected a results event, got %+v", ev)
+		}
+		if err := json.Unmarshal([]byte(ev.data), &result); err != nil {
+			t.Fatalf("Expected JSON data, got %q", ev.data)
+		}
+		if ev.id != strconv.FormatUint(result.Version, 10) {
+			t.Errorf("Expected the event ID to be the tally version, got %+v", ev)
+		}
+	}
+	if result.Results["Option 1"] != 1 || result.Results["Option 2"] != 1 {
+		t.Errorf("Unexpected results %v", result.Results)
+	}
+}
+
+func TestPollUpdatesResume(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	cfg := streamConfig()
+	cfg.HeartbeatInterval = 20 * time.Millisecond
+	handler := NewHTTPHandler(mockService, cfg)
+	server := httptest.NewServer(http.HandlerFunc(handler.PollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+
+	// The client already has version 1, so only a heartbeat comes until
+	// the next vote
+	_, stream := openStream(t, server.URL+"/poll_updates/1", "1")
+	readEvent(t, stream)
+	if ev := readEvent(t, stream); ev.comment != "heartbeat" {
+		t.Fatalf("Expected a heartbeat, got %+v", ev)
+	}
+
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+	ev := readEvent(t, stream)
+	for ev.comment != "" {
+		ev = readEvent(t, stream)
+	}
+	if ev.name != eventResults || ev.id != "2" {
+		t.Errorf("Expected results event 2, got %+v", ev)
+	}
+}
+
+func TestPollUpdatesClosed(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, streamConfig())
+	server := httptest.NewServer(http.HandlerFunc(handler.PollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	closesAt := time.Now().Add(100 * time.Millisecond)
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}, ClosesAt: &closesAt})
+
+	_, stream := openStream(t, server.URL+"/poll_updates/1", "")
+	readEvent(t, stream)
+	if ev := readEvent(t, stream); ev.name != eventResults {
+		t.Fatalf("Expected results first, got %+v", ev)
+	}
+	if ev := readEvent(t, stream); ev.name != eventClosed || ev.id != "0" {
+		t.Errorf("Expected a closed event once the poll closes, got %+v", ev)
+	}
+	if _, err := stream.ReadString('\n'); err == nil {
+		t.Error("Expected the stream to end after the closed event")
+	}
+
+	resp, _ := openStream(t, server.URL+"/poll_updates/1", "0")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 for a client that saw the final tally, got %d", resp.StatusCode)
+	}
+}
+
+func TestPollUpdatesDelta(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	cfg := streamConfig()
+	cfg.ResyncInterval = 300 * time.Millisecond
+	handler := NewHTTPHandler(mockService, cfg)
+	server := httptest.NewServer(http.HandlerFunc(handler.PollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	_, stream := openStream(t, server.URL+"/poll_updates/1?mode=delta", "")
+	readEvent(t, stream)
+	if ev := readEvent(t, stream); ev.name != eventResults || ev.id != "1" {
+		t.Fatalf("Expected the full tally first, got %+v", ev)
+	}
+
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	ev := readEvent(t, stream)
+	if ev.name != eventDelta || ev.id != "2" {
+		t.Fatalf("Expected delta event 2, got %+v", ev)
+	}
+	var delta resultsDelta
+	_ = json.Unmarshal([]byte(ev.data), &delta)
+	if delta.Since != 1 || delta.Version != 2 || len(delta.Results) != 1 || delta.Results["Option 1"] != 1 {
+		t.Errorf("Expected only Option 1 to change from version 1 to 2, got %+v", delta)
+	}
+
+	time.Sleep(cfg.ResyncInterval)
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	if ev := readEvent(t, stream); ev.name != eventResults || ev.id != "3" {
+		t.Errorf("Expected a full resync after the resync interval, got %+v", ev)
+	}
+}
+
+func TestPollUpdatesCoalescesBursts(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	cfg := DefaultConfig()
+	cfg.UpdateInterval = 200 * time.Millisecond
+	handler := NewHTTPHandler(mockService, cfg)
+	server := httptest.NewServer(http.HandlerFunc(handler.PollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	_, stream := openStream(t, server.URL+"/poll_updates/1?mode=delta", "")
+	readEvent(t, stream)
+	readEvent(t, stream)
+	for i := 0; i < 5; i++ {
+		_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	}
+
+	ev := readEvent(t, stream)
+	var delta resultsDelta
+	_ = json.Unmarshal([]byte(ev.data), &delta)
+	if ev.name != eventDelta || delta.Since != 0 || delta.Version != 5 || delta.Results["Option 1"] != 5 {
+		t.Errorf("Expected one delta for the whole burst, got %+v", ev)
+	}
+}
+
+func TestPollUpdatesInvalidMode(t *testing.T) {
+	handler := NewHTTPHandler(mocks.NewMockPollService(), DefaultConfig())
+	rr := httptest.NewRecorder()
+	handler.PollUpdatesHandler(rr, httptest.NewRequest("GET", "/poll_updates/1?mode=diff", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown mode, got %d", rr.Code)
+	}
+}
+
+// disappearingService reports the poll as gone after the first read.
+type disappearingService struct {
+	ports.PollService
+	reads atomic.Int32
+}
+
+func (s *disappearingService) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	if s.reads.Add(1) > 1 {
+		return domain.PollResult{}, domain.ErrPollNotFound
+	}
+	return s.PollService.GetResults(ctx, pollID)
+}
+
+func TestPollUpdatesDeleted(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(&disappearingService{PollService: mockService}, streamConfig())
+	server := httptest.NewServer(http.HandlerFunc(handler.PollUpdatesHandler))
+	t.Cleanup(server.Close)
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+
+	_, stream := openStream(t, server.URL+"/poll_updates/1", "")
+	readEvent(t, stream)
+	readEvent(t, stream)
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	if ev := readEvent(t, stream); ev.name != eventDeleted || ev.data != `{"poll_id":"1"}` {
+		t.Errorf("Expected a deleted event, got %+v", ev)
+	}
+}
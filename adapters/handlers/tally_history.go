@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errBadHistoryQuery = errors.New("step must be a positive duration and points a positive number")
+
+// TallyHistoryHandler serves /polls/{id}/history: the poll's tally over
+// time, thinned to one point per ?step=1m and to at most ?points=100.
+func (h *HTTPHandler) TallyHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pollID, ok := pollIDFromPath(r)
+	if !ok {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	var step time.Duration
+	if s := query.Get("step"); s != "" {
+		var err error
+		if step, err = time.ParseDuration(s); err != nil || step <= 0 {
+			http.Error(w, errBadHistoryQuery.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var points int
+	if s := query.Get("points"); s != "" {
+		var err error
+		if points, err = strconv.Atoi(s); err != nil || points <= 0 {
+			http.Error(w, errBadHistoryQuery.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	history, err := h.pollService.TallyHistory(r.Context(), pollID, step, points)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestTallyHistoryHandler(t *testing.T) {
+	ctx := context.Background()
+	mockService := mocks.NewMockPollService()
+	handler := NewHTTPHandler(mockService, DefaultConfig())
+
+	_ = mockService.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	_, _ = mockService.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	tests := []struct {
+		query  string
+		status int
+		points int
+	}{
+		{"", http.StatusOK, 3},
+		{"?points=1", http.StatusOK, 1},
+		{"?step=1h", http.StatusOK, 1},
+		{"?step=soon", http.StatusBadRequest, 0},
+		{"?step=-1m", http.StatusBadRequest, 0},
+		{"?points=0", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.TallyHistoryHandler(rr, httptest.NewRequest("GET", "/polls/1/history"+tt.query, nil))
+		if rr.Code != tt.status {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.status, rr.Code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var history domain.TallyHistory
+		if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+			t.Fatal(err)
+		}
+		if len(history.Points) != tt.points {
+			t.Errorf("%q: expected %d points, got %+v", tt.query, tt.points, history.Points)
+			continue
+		}
+		if last := history.Points[len(history.Points)-1]; last.Results["Option 1"] != 1 || last.Results["Option 2"] != 1 {
+			t.Errorf("%q: expected the current tally last, got %+v", tt.query, last)
+		}
+		if first := history.Points[0]; tt.points > 1 && (first.Results["Option 1"] != 0 || first.Results["Option 2"] != 0) {
+			t.Errorf("%q: expected an empty tally first, got %+v", tt.query, first)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler.TallyHistoryHandler(rr, httptest.NewRequest("POST", "/polls/1/history", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rr.Code)
+	}
+}
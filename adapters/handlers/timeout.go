@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithTimeout gives each request a deadline that is carried through the
+// service and repository calls it makes. Long-lived streams should not be
+// wrapped. A zero timeout disables the deadline.
+func WithTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	if timeout <= 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
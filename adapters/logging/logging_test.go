@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func TestMiddlewareAssignsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, FormatJSON, "info")
+
+	var seen string
+	handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/create_poll", nil))
+
+	if seen == "" || rr.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("Expected generated request ID %q to be echoed, got %q", seen, rr.Header().Get(RequestIDHeader))
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON access log line, got %q", buf.String())
+	}
+	if entry["request_id"] != seen || entry["status"] != float64(http.StatusCreated) || entry["path"] != "/create_poll" {
+		t.Errorf("Unexpected access log entry: %v", entry)
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Errorf("Expected access log to carry a duration: %v", entry)
+	}
+}
+
+func TestMiddlewarePropagatesRequestID(t *testing.T) {
+	logger, _ := New(&bytes.Buffer{}, FormatText, "info")
+
+	var seen string
+	handler := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	for _, tt := range []struct {
+		incoming string
+		keep     bool
+	}{
+		{"abc-123", true},
+		{"has space", false},
+		{strings.Repeat("x", 200), false},
+	} {
+		req := httptest.NewRequest("GET", "/results/1", nil)
+		req.Header.Set(RequestIDHeader, tt.incoming)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if (seen == tt.incoming) != tt.keep {
+			t.Errorf("Incoming ID %q: got %q, keep=%v", tt.incoming, seen, tt.keep)
+		}
+		if rr.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("Expected response to echo %q, got %q", seen, rr.Header().Get(RequestIDHeader))
+		}
+	}
+}
+
+func TestPollServiceLogsVotes(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger, _ := New(&buf, FormatJSON, "info")
+	service := NewPollService(mocks.NewMockPollService(), logger)
+
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1", VoterID: "alice"})
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "missing", Option: "Option 1", VoterID: "bob"})
+
+	var entries []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 log entries, got %d: %v", len(entries), entries)
+	}
+	if entries[1]["msg"] != "vote accepted" || entries[1]["poll_id"] != "1" || entries[1]["voter_id"] != "alice" {
+		t.Errorf("Unexpected accepted vote entry: %v", entries[1])
+	}
+	if entries[2]["msg"] != "vote rejected" || entries[2]["level"] != "WARN" || entries[2]["voter_id"] != "bob" {
+		t.Errorf("Unexpected rejected vote entry: %v", entries[2])
+	}
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("Expected an error for an unknown format, got nil")
+	}
+	if _, err := New(&bytes.Buffer{}, FormatJSON, "loud"); err == nil {
+		t.Error("Expected an error for an unknown level, got nil")
+	}
+}
@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+// PollService logs poll creation and every accepted or rejected vote with
+// the poll and voter IDs involved.
+type PollService struct {
+	next   ports.PollService
+	logger *slog.Logger
+}
+
+func NewPollService(next ports.PollService, logger *slog.Logger) *PollService {
+	return &PollService{next: next, logger: logger}
+}
+
+func (s *PollService) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	err := s.next.CreatePoll(ctx, poll)
+	if err != nil {
+		s.log(ctx, slog.LevelWarn, "poll rejected", "poll_id", poll.ID, "error", err)
+		return err
+	}
+	s.log(ctx, slog.LevelInfo, "poll created", "poll_id", poll.ID, "options", len(poll.Options))
+	return nil
+}
+
+func (s *PollService) Vote(ctx context.Context, vote domain.Vote) (domain.Ballot, error) {
+	ballot, err := s.next.Vote(ctx, vote)
+	if err != nil {
+		s.voteRejected(ctx, vote, err)
+		return ballot, err
+	}
+	s.voteAccepted(ctx, vote)
+	return ballot, nil
+}
+
+func (s *PollService) VoteMultiple(ctx context.Context, votes domain.MultiVote) ([]domain.Ballot, error) {
+	ballots, err := s.next.VoteMultiple(ctx, votes)
+	for i := range ballots {
+		s.voteAccepted(ctx, votes.Votes[i])
+	}
+	if err != nil && len(ballots) < len(votes.Votes) {
+		s.voteRejected(ctx, votes.Votes[len(ballots)], err)
+	}
+	return ballots, err
+}
+
+func (s *PollService) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	return s.next.GetResults(ctx, pollID)
+}
+
+func (s *PollService) TallyCommitment(ctx context.Context, pollID string) (domain.TallyCommitment, error) {
+	return s.next.TallyCommitment(ctx, pollID)
+}
+
+func (s *PollService) VerifyReceipt(ctx context.Context, receipt domain.Receipt) (domain.ReceiptVerification, error) {
+	verification, err := s.next.VerifyReceipt(ctx, receipt)
+	if err == nil && !verification.Valid {
+		s.log(ctx, slog.LevelWarn, "receipt verification failed", "poll_id", receipt.PollID, "reason", verification.Reason)
+	}
+	return verification, err
+}
+
+func (s *PollService) AuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	return s.next.AuditLog(ctx, pollID)
+}
+
+func (s *PollService) ResultsChanged(ctx context.Context, pollID string) (<-chan struct{}, error) {
+	return s.next.ResultsChanged(ctx, pollID)
+}
+
+func (s *PollService) ClosePoll(ctx context.Context, pollID string) error {
+	err := s.next.ClosePoll(ctx, pollID)
+	if err == nil {
+		s.log(ctx, slog.LevelInfo, "poll closed", "poll_id", pollID)
+	}
+	return err
+}
+
+func (s *PollService) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	err := s.next.RetractVote(ctx, pollID, voterID, nonce)
+	if err != nil {
+		s.log(ctx, slog.LevelWarn, "retraction rejected", "poll_id", pollID, "voter_id", voterID, "error", err)
+		return err
+	}
+	s.log(ctx, slog.LevelInfo, "vote retracted", "poll_id", pollID, "voter_id", voterID)
+	return nil
+}
+
+func (s *PollService) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	return s.next.ResultsAt(ctx, pollID, at)
+}
+
+func (s *PollService) PollEvents(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	return s.next.PollEvents(ctx, pollID)
+}
+
+func (s *PollService) TallyHistory(ctx context.Context, pollID string, step time.Duration, maxPoints int) (domain.TallyHistory, error) {
+	return s.next.TallyHistory(ctx, pollID, step, maxPoints)
+}
+
+func (s *PollService) voteAccepted(ctx context.Context, vote domain.Vote) {
+	s.log(ctx, slog.LevelInfo, "vote accepted", "poll_id", vote.PollID, "voter_id", vote.VoterID)
+}
+
+func (s *PollService) voteRejected(ctx context.Context, vote domain.Vote, err error) {
+	s.log(ctx, slog.LevelWarn, "vote rejected", "poll_id", vote.PollID, "voter_id", vote.VoterID, "error", err)
+}
+
+// log tags the event with the request and trace it belongs to so service
+// events can be matched to access logs and spans.
+func (s *PollService) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if id := RequestID(ctx); id != "" {
+		args = append(args, "request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		args = append(args, "trace_id", sc.TraceID().String())
+	}
+	s.logger.Log(ctx, level, msg, args...)
+}
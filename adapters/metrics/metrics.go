@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"polling-system/domain"
+	"polling-system/internal/httpx"
+)
+
+type Metrics struct {
+	registry           *prometheus.Registry
+	requests           *prometheus.CounterVec
+	requestDuration    *prometheus.HistogramVec
+	votes              *prometheus.CounterVec
+	subscribers        *prometheus.GaugeVec
+	repositoryDuration *prometheus.HistogramVec
+
+	mu      sync.Mutex
+	streams map[string]int
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		streams:  make(map[string]int),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "polling_http_requests_total",
+			Help: "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "polling_http_request_duration_seconds",
+			Help:    "HTTP request latency by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		votes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "polling_votes_total",
+			Help: "Votes by outcome (accepted or rejected) and rejection reason.",
+		}, []string{"result", "reason"}),
+		subscribers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "polling_sse_subscribers",
+			Help: "Open live update streams per poll.",
+		}, []string{"poll_id"}),
+		repositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "polling_repository_operation_duration_seconds",
+			Help:    "Repository operation latency by operation and outcome.",
+			Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation", "outcome"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.votes,
+		m.subscribers,
+		m.repositoryDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// InstrumentRoute records request count and latency for next under the
+// route label, which should be the mux pattern rather than the raw path to
+// keep label cardinality bounded.
+func (m *Metrics) InstrumentRoute(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := httpx.NewRecorder(w)
+		next(rec, r)
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(rec.Status)).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// TrackSubscribers counts the open streams of a live update route per poll,
+// using the {id} wildcard of the matched pattern, or the comma-separated
+// ids query parameter of a multi-poll stream. A stream counts once it
+// opens, so requests for unknown polls add no series, and a poll's series
+// is removed when its last stream ends.
+func (m *Metrics) TrackSubscribers(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pollIDs := streamPollIDs(r)
+		opened := false
+		rec := httpx.NewRecorder(w)
+		rec.OnHeader = func(status int) {
+			if status == http.StatusOK {
+				opened = true
+				for _, pollID := range pollIDs {
+					m.subscribe(pollID, 1)
+				}
+			}
+		}
+		next(rec, r)
+		if opened {
+			for _, pollID := range pollIDs {
+				m.subscribe(pollID, -1)
+			}
+		}
+	}
+}
+
+func streamPollIDs(r *http.Request) []string {
+	if pollID := r.PathValue("id"); pollID != "" {
+		return []string{pollID}
+	}
+	var pollIDs []string
+	for _, pollID := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		pollID = strings.TrimSpace(pollID)
+		if pollID != "" && !slices.Contains(pollIDs, pollID) {
+			pollIDs = append(pollIDs, pollID)
+		}
+	}
+	return pollIDs
+}
+
+// subscribe adds delta to the open streams of a poll.
+func (m *Metrics) subscribe(pollID string, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streams[pollID] += delta
+	if m.streams[pollID] > 0 {
+		m.subscribers.WithLabelValues(pollID).Set(float64(m.streams[pollID]))
+		return
+	}
+	delete(m.streams, pollID)
+	m.subscribers.DeleteLabelValues(pollID)
+}
+
+func (m *Metrics) voteAccepted(n int) {
+	if n > 0 {
+		m.votes.WithLabelValues("accepted", "").Add(float64(n))
+	}
+}
+
+func (m *Metrics) voteRejected(err error) {
+	m.votes.WithLabelValues("rejected", rejectReason(err)).Inc()
+}
+
+func (m *Metrics) observeRepository(operation string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.repositoryDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrPollNotFound):
+		return "poll_not_found"
+	case errors.Is(err, domain.ErrInvalidOption):
+		return "invalid_option"
+	case errors.Is(err, domain.ErrPollClosed):
+		return "poll_closed"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
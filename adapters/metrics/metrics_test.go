@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/mocks"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rr.Body)
+	return string(body)
+}
+
+func expectMetric(t *testing.T, exposition, line string) {
+	t.Helper()
+	if !strings.Contains(exposition, line) {
+		t.Errorf("Expected metrics to contain %q", line)
+	}
+}
+
+func TestInstrumentRoute(t *testing.T) {
+	m := New()
+	handler := m.InstrumentRoute("/results/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "poll not found", http.StatusNotFound)
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/results/1", nil))
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/results/2", nil))
+
+	out := scrape(t, m)
+	expectMetric(t, out, `polling_http_requests_total{code="404",method="GET",route="/results/{id}"} 2`)
+	expectMetric(t, out, `polling_http_request_duration_seconds_count{method="GET",route="/results/{id}"} 2`)
+}
+
+func TestPollServiceCountsVotes(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	service := NewPollService(mocks.NewMockPollService(), m)
+
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "missing", Option: "Option 1"})
+	_, _ = service.VoteMultiple(ctx, domain.MultiVote{Votes: []domain.Vote{
+		{PollID: "1", Option: "Option 1"},
+		{PollID: "1", Option: "Option 2"},
+		{PollID: "missing", Option: "Option 2"},
+	}})
+
+	out := scrape(t, m)
+	expectMetric(t, out, `polling_votes_total{reason="",result="accepted"} 3`)
+	expectMetric(t, out, `polling_votes_total{reason="poll_not_found",result="rejected"} 2`)
+}
+
+func TestPollRepositoryObservesOperations(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	repo := NewPollRepository(mocks.NewMockRepository(), m)
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+	_, _ = repo.GetResults(ctx, "missing")
+
+	out := scrape(t, m)
+	expectMetric(t, out, `polling_repository_operation_duration_seconds_count{operation="create_poll",outcome="ok"} 1`)
+	expectMetric(t, out, `polling_repository_operation_duration_seconds_count{operation="vote",outcome="ok"} 1`)
+	expectMetric(t, out, `polling_repository_operation_duration_seconds_count{operation="get_results",outcome="error"} 1`)
+}
+
+func TestTrackSubscribers(t *testing.T) {
+	m := New()
+	inside := make(chan string)
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/poll_updates/{id}", m.TrackSubscribers(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "7" {
+			http.Error(w, "poll not found", http.StatusNotFound)
+			return
+		}
+		w.(http.Flusher).Flush()
+		inside <- scrape(t, m)
+		<-release
+	}))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/poll_updates/missing", nil))
+	if strings.Contains(scrape(t, m), `poll_id="missing"`) {
+		t.Error("Expected no series for a stream that never opened")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/poll_updates/7", nil))
+		close(done)
+	}()
+
+	expectMetric(t, <-inside, `polling_sse_subscribers{poll_id="7"} 1`)
+	close(release)
+	<-done
+	if strings.Contains(scrape(t, m), `poll_id="7"`) {
+		t.Error("Expected the series to be removed once the last stream ended")
+	}
+}
+
+func TestTrackSubscribersMultiPoll(t *testing.T) {
+	m := New()
+	inside := make(chan string)
+	release := make(chan struct{})
+	handler := m.TrackSubscribers(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		inside <- scrape(t, m)
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/poll_updates?ids=1,2,1", nil))
+		close(done)
+	}()
+
+	out := <-inside
+	expectMetric(t, out, `polling_sse_subscribers{poll_id="1"} 1`)
+	expectMetric(t, out, `polling_sse_subscribers{poll_id="2"} 1`)
+	close(release)
+	<-done
+	if strings.Contains(scrape(t, m), "polling_sse_subscribers{") {
+		t.Error("Expected the series to be removed once the stream ended")
+	}
+}
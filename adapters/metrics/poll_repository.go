@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+// PollRepository records the latency of every operation on next.
+type PollRepository struct {
+	next    ports.PollRepository
+	metrics *Metrics
+}
+
+func NewPollRepository(next ports.PollRepository, metrics *Metrics) *PollRepository {
+	return &PollRepository{next: next, metrics: metrics}
+}
+
+func (r *PollRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	start := time.Now()
+	err := r.next.CreatePoll(ctx, poll)
+	r.metrics.observeRepository("create_poll", start, err)
+	return err
+}
+
+func (r *PollRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	start := time.Now()
+	poll, err := r.next.GetPoll(ctx, id)
+	r.metrics.observeRepository("get_poll", start, err)
+	return poll, err
+}
+
+func (r *PollRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	start := time.Now()
+	err := r.next.Vote(ctx, vote, commitment)
+	r.metrics.observeRepository("vote", start, err)
+	return err
+}
+
+func (r *PollRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	start := time.Now()
+	result, err := r.next.GetResults(ctx, pollID)
+	r.metrics.observeRepository("get_results", start, err)
+	return result, err
+}
+
+func (r *PollRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	start := time.Now()
+	commitments, err := r.next.GetCommitments(ctx, pollID)
+	r.metrics.observeRepository("get_commitments", start, err)
+	return commitments, err
+}
+
+func (r *PollRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	start := time.Now()
+	entries, err := r.next.GetAuditLog(ctx, pollID)
+	r.metrics.observeRepository("get_audit_log", start, err)
+	return entries, err
+}
+
+func (r *PollRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	start := time.Now()
+	err := r.next.AddTallySnapshot(ctx, pollID, snap)
+	r.metrics.observeRepository("add_tally_snapshot", start, err)
+	return err
+}
+
+func (r *PollRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	start := time.Now()
+	history, err := r.next.GetTallyHistory(ctx, pollID)
+	r.metrics.observeRepository("get_tally_history", start, err)
+	return history, err
+}
+
+// Flush passes through to next so wrapping a persistent repository doesn't
+// hide it from shutdown.
+func (r *PollRepository) Flush() error {
+	if flusher, ok := r.next.(ports.Flusher); ok {
+		start := time.Now()
+		err := flusher.Flush()
+		r.metrics.observeRepository("flush", start, err)
+		return err
+	}
+	return nil
+}
+
+// Ready passes through to next; repositories without a readiness check are
+// assumed ready.
+func (r *PollRepository) Ready(ctx context.Context) error {
+	if checker, ok := r.next.(ports.ReadinessChecker); ok {
+		return checker.Ready(ctx)
+	}
+	return nil
+}
+
+// history returns next's history support; wrapping a repository must not
+// hide it from the service.
+func (r *PollRepository) history() (ports.PollHistory, error) {
+	if history, ok := r.next.(ports.PollHistory); ok {
+		return history, nil
+	}
+	return nil, domain.ErrNoHistory
+}
+
+func (r *PollRepository) ClosePoll(ctx context.Context, pollID string) error {
+	history, err := r.history()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = history.ClosePoll(ctx, pollID)
+	r.metrics.observeRepository("close_poll", start, err)
+	return err
+}
+
+func (r *PollRepository) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	history, err := r.history()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = history.RetractVote(ctx, pollID, voterID, nonce)
+	r.metrics.observeRepository("retract_vote", start, err)
+	return err
+}
+
+func (r *PollRepository) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	history, err := r.history()
+	if err != nil {
+		return domain.PollResult{}, err
+	}
+	start := time.Now()
+	result, err := history.ResultsAt(ctx, pollID, at)
+	r.metrics.observeRepository("results_at", start, err)
+	return result, err
+}
+
+func (r *PollRepository) Events(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	history, err := r.history()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	events, err := history.Events(ctx, pollID)
+	r.metrics.observeRepository("events", start, err)
+	return events, err
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+// PollService counts accepted and rejected votes passing through next.
+type PollService struct {
+	next    ports.PollService
+	metrics *Metrics
+}
+
+func NewPollService(next ports.PollService, metrics *Metrics) *PollService {
+	return &PollService{next: next, metrics: metrics}
+}
+
+func (s *PollService) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	return s.next.CreatePoll(ctx, poll)
+}
+
+func (s *PollService) Vote(ctx context.Context, vote domain.Vote) (domain.Ballot, error) {
+	ballot, err := s.next.Vote(ctx, vote)
+	if err != nil {
+		s.metrics.voteRejected(err)
+		return ballot, err
+	}
+	s.metrics.voteAccepted(1)
+	return ballot, nil
+}
+
+func (s *PollService) VoteMultiple(ctx context.Context, votes domain.MultiVote) ([]domain.Ballot, error) {
+	ballots, err := s.next.VoteMultiple(ctx, votes)
+	// Votes before the failing one have already been recorded
+	s.metrics.voteAccepted(len(ballots))
+	if err != nil {
+		s.metrics.voteRejected(err)
+	}
+	return ballots, err
+}
+
+func (s *PollService) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	return s.next.GetResults(ctx, pollID)
+}
+
+func (s *PollService) TallyCommitment(ctx context.Context, pollID string) (domain.TallyCommitment, error) {
+	return s.next.TallyCommitment(ctx, pollID)
+}
+
+func (s *PollService) VerifyReceipt(ctx context.Context, receipt domain.Receipt) (domain.ReceiptVerification, error) {
+	return s.next.VerifyReceipt(ctx, receipt)
+}
+
+func (s *PollService) AuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	return s.next.AuditLog(ctx, pollID)
+}
+
+func (s *PollService) ResultsChanged(ctx context.Context, pollID string) (<-chan struct{}, error) {
+	return s.next.ResultsChanged(ctx, pollID)
+}
+
+func (s *PollService) ClosePoll(ctx context.Context, pollID string) error {
+	return s.next.ClosePoll(ctx, pollID)
+}
+
+func (s *PollService) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	return s.next.RetractVote(ctx, pollID, voterID, nonce)
+}
+
+func (s *PollService) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	return s.next.ResultsAt(ctx, pollID, at)
+}
+
+func (s *PollService) PollEvents(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	return s.next.PollEvents(ctx, pollID)
+}
+
+func (s *PollService) TallyHistory(ctx context.Context, pollID string, step time.Duration, maxPoints int) (domain.TallyHistory, error) {
+	return s.next.TallyHistory(ctx, pollID, step, maxPoints)
+}
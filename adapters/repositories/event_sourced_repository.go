@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+// EventSourcedRepository stores every change to a poll as an event in an
+// EventStore. Current results, the audit log, commitments and tally history
+// are read models projected from the events, cached per poll and rebuilt
+// from the store whenever they are missing.
+type EventSourcedRepository struct {
+	store ports.EventStore
+	mu    sync.RWMutex
+	polls map[string]*pollProjection
+}
+
+// pollProjection holds one poll's aggregate and read models; mu orders
+// commands on the poll.
+type pollProjection struct {
+	mu          sync.Mutex
+	aggregate   *domain.PollAggregate
+	tally       *domain.TallyProjection
+	audit       []domain.AuditEntry
+	commitments []string
+	history     []domain.TallySnapshot
+}
+
+func NewEventSourcedRepository(store ports.EventStore) *EventSourcedRepository {
+	return &EventSourcedRepository{store: store, polls: make(map[string]*pollProjection)}
+}
+
+func (r *EventSourcedRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	return r.execute(ctx, poll.ID, true, func(p *pollProjection, now time.Time) (domain.PollEvent, error) {
+		if poll.CreatedAt != nil {
+			now = *poll.CreatedAt
+		}
+		return p.aggregate.Create(poll, now)
+	})
+}
+
+func (r *EventSourcedRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	result, err := r.GetResults(ctx, id)
+	return result.Poll, err
+}
+
+func (r *EventSourcedRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	return r.execute(ctx, vote.PollID, false, func(p *pollProjection, now time.Time) (domain.PollEvent, error) {
+		return p.aggregate.Cast(vote, commitment, now)
+	})
+}
+
+func (r *EventSourcedRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	p, err := r.projection(ctx, pollID, false)
+	if err != nil {
+		return domain.PollResult{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tally.Result(), nil
+}
+
+func (r *EventSourcedRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	p, err := r.projection(ctx, pollID, false)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.commitments), nil
+}
+
+func (r *EventSourcedRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	p, err := r.projection(ctx, pollID, false)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.audit), nil
+}
+
+func (r *EventSourcedRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	return r.execute(ctx, pollID, false, func(p *pollProjection, now time.Time) (domain.PollEvent, error) {
+		return p.aggregate.RecordSnapshot(snap, now)
+	})
+}
+
+func (r *EventSourcedRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	p, err := r.projection(ctx, pollID, false)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.history), nil
+}
+
+func (r *EventSourcedRepository) ClosePoll(ctx context.Context, pollID string) error {
+	return r.execute(ctx, pollID, false, func(p *pollProjection, now time.Time) (domain.PollEvent, error) {
+		return p.aggregate.Close(now)
+	})
+}
+
+func (r *EventSourcedRepository) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	return r.execute(ctx, pollID, false, func(p *pollProjection, now time.Time) (domain.PollEvent, error) {
+		return p.aggregate.Retract(voterID, nonce, now)
+	})
+}
+
+func (r *EventSourcedRepository) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	events, err := r.Events(ctx, pollID)
+	if err != nil {
+		return domain.PollResult{}, err
+	}
+	return domain.ProjectTally(events, at)
+}
+
+func (r *EventSourcedRepository) Events(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	events, err := r.store.Load(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, domain.ErrPollNotFound
+	}
+	return events, nil
+}
+
+// Rebuild drops every read model; each is replayed from the store the next
+// time its poll is used.
+func (r *EventSourcedRepository) Rebuild() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.polls)
+}
+
+// Close closes the event store if it holds resources such as a file.
+func (r *EventSourcedRepository) Close() error {
+	if closer, ok := r.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// execute decides a command against the poll's aggregate and stores the
+// resulting event. A conflicting append means another writer got there
+// first, so the projection is replayed and the command decided again.
+func (r *EventSourcedRepository) execute(ctx context.Context, pollID string, create bool, decide func(*pollProjection, time.Time) (domain.PollEvent, error)) error {
+	for {
+		p, err := r.projection(ctx, pollID, create)
+		if err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		e, err := decide(p, time.Now())
+		if err == nil {
+			err = r.store.Append(ctx, pollID, p.aggregate.Version(), e)
+		}
+		if err == nil {
+			err = p.apply(e)
+		}
+		p.mu.Unlock()
+
+		if !errors.Is(err, domain.ErrStreamConflict) {
+			return err
+		}
+		r.evict(pollID, p)
+	}
+}
+
+// projection returns the poll's cached read models, replaying its events on
+// a miss. Unknown polls are ErrPollNotFound unless create is set.
+func (r *EventSourcedRepository) projection(ctx context.Context, pollID string, create bool) (*pollProjection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	p, ok := r.polls[pollID]
+	r.mu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	events, err := r.store.Load(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 && !create {
+		return nil, domain.ErrPollNotFound
+	}
+	p = &pollProjection{aggregate: domain.NewPollAggregate(pollID), tally: domain.NewTallyProjection()}
+	for _, e := range events {
+		if err := p.apply(e); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.polls[pollID]; ok {
+		return cached, nil
+	}
+	if len(events) > 0 {
+		r.polls[pollID] = p
+	}
+	return p, nil
+}
+
+func (r *EventSourcedRepository) evict(pollID string, p *pollProjection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.polls[pollID] == p {
+		delete(r.polls, pollID)
+	}
+}
+
+func (p *pollProjection) apply(e domain.PollEvent) error {
+	if err := p.aggregate.Apply(e); err != nil {
+		return err
+	}
+	p.tally.Apply(e)
+	switch e.Type {
+	case domain.VoteCast:
+		p.audit = append(p.audit, domain.NewAuditEntry(p.lastAudit(), domain.Vote{PollID: e.PollID, Option: e.Option}, e.At))
+		if e.Commitment != "" {
+			p.commitments = append(p.commitments, e.Commitment)
+		}
+	case domain.VoteRetracted:
+		p.audit = append(p.audit, domain.NewRetractionEntry(p.lastAudit(), domain.Vote{PollID: e.PollID, Option: e.Option}, e.At))
+		// A retracted ballot no longer counts, so it must not prove inclusion
+		if i := slices.Index(p.commitments, e.Commitment); e.Commitment != "" && i >= 0 {
+			p.commitments = slices.Delete(p.commitments, i, i+1)
+		}
+	case domain.CommitmentAdded:
+		p.commitments = append(p.commitments, e.Commitment)
+	case domain.TallySnapshotted:
+		p.history = append(p.history, *e.Snapshot)
+	}
+	return nil
+}
+
+func (p *pollProjection) lastAudit() *domain.AuditEntry {
+	if len(p.audit) == 0 {
+		return nil
+	}
+	return &p.audit[len(p.audit)-1]
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"polling-system/adapters/eventstore"
+	"polling-system/domain"
+)
+
+func TestEventSourcedRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewEventSourcedRepository(eventstore.NewMemory())
+
+	poll := domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}}
+	if err := repo.CreatePoll(ctx, poll); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := repo.CreatePoll(ctx, poll); !errors.Is(err, domain.ErrPollExists) {
+		t.Errorf("Expected ErrPollExists, got %v", err)
+	}
+	if err := repo.Vote(ctx, domain.Vote{PollID: "2", Option: "Option 1"}, ""); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound, got %v", err)
+	}
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 3"}, ""); !errors.Is(err, domain.ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+
+	alice := domain.Vote{PollID: "1", Option: "Option 1", VoterID: "alice"}
+	_ = repo.Vote(ctx, alice, domain.BallotCommitment(alice, "n1"))
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2", VoterID: "bob"}, "")
+	if err := repo.RetractVote(ctx, "1", "alice", "n2"); !errors.Is(err, domain.ErrNotBallotOwner) {
+		t.Errorf("Expected ErrNotBallotOwner for the wrong nonce, got %v", err)
+	}
+	if err := repo.RetractVote(ctx, "1", "alice", "n1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := repo.RetractVote(ctx, "1", "alice", "n1"); !errors.Is(err, domain.ErrNoVote) {
+		t.Errorf("Expected ErrNoVote, got %v", err)
+	}
+
+	result, err := repo.GetResults(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Results["Option 1"] != 0 || result.Results["Option 2"] != 1 || result.Version != 3 {
+		t.Errorf("Expected one vote for Option 2 at version 3, got %v at %d", result.Results, result.Version)
+	}
+	if result.Poll.CreatedAt == nil {
+		t.Error("Expected the poll to record its creation time")
+	}
+
+	// The retraction is chained into the audit log and subtracted again
+	entries, _ := repo.GetAuditLog(ctx, "1")
+	if len(entries) != 3 || !entries[2].Retraction || entries[2].Option != "Option 1" {
+		t.Fatalf("Expected two votes and a retraction in the audit log, got %+v", entries)
+	}
+	tally, err := domain.VerifyAuditLog("1", entries)
+	if err != nil || tally["Option 1"] != 0 || tally["Option 2"] != 1 {
+		t.Errorf("Expected the audit log to count one vote for Option 2, got %v, %v", tally, err)
+	}
+	entries[2].Retraction = false
+	if _, err := domain.VerifyAuditLog("1", entries); err == nil {
+		t.Error("Expected turning a retraction into a vote to break the chain")
+	}
+
+	if err := repo.ClosePoll(ctx, "1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, ""); !errors.Is(err, domain.ErrPollClosed) {
+		t.Errorf("Expected ErrPollClosed, got %v", err)
+	}
+	if closed, _ := repo.GetPoll(ctx, "1"); closed.Open(time.Now()) {
+		t.Error("Expected the poll to be closed")
+	}
+	if closed, _ := repo.GetResults(ctx, "1"); closed.Version != 4 {
+		t.Errorf("Expected closing to bump the version to 4, got %d", closed.Version)
+	}
+
+	events, _ := repo.Events(ctx, "1")
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []string{domain.PollCreated, domain.VoteCast, domain.VoteCast, domain.VoteRetracted, domain.PollClosed}
+	if len(types) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("Expected events %v, got %v", want, types)
+			break
+		}
+	}
+}
+
+func TestEventSourcedResultsAt(t *testing.T) {
+	ctx := context.Background()
+	store := eventstore.NewMemory()
+	repo := NewEventSourcedRepository(store)
+
+	// Events carry their own times, so a stream can be written as it
+	// would have happened over ten minutes
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	poll := domain.Poll{ID: "1", Options: []string{"Yes", "No"}, CreatedAt: &created}
+	stream := []domain.PollEvent{
+		{Type: domain.PollCreated, Poll: &poll, At: created},
+		{Type: domain.VoteCast, Option: "Yes", At: created.Add(time.Minute)},
+		{Type: domain.VoteCast, Option: "No", At: created.Add(4 * time.Minute)},
+		{Type: domain.VoteCast, Option: "No", At: created.Add(8 * time.Minute)},
+	}
+	for i := range stream {
+		stream[i].Seq = i
+		stream[i].PollID = "1"
+	}
+	_ = store.Append(ctx, "1", 0, stream...)
+
+	tests := []struct {
+		at   time.Duration
+		want map[string]int
+	}{
+		{0, map[string]int{}},
+		{5 * time.Minute, map[string]int{"Yes": 1, "No": 1}},
+		{time.Hour, map[string]int{"Yes": 1, "No": 2}},
+	}
+	for _, tt := range tests {
+		result, err := repo.ResultsAt(ctx, "1", created.Add(tt.at))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Results["Yes"] != tt.want["Yes"] || result.Results["No"] != tt.want["No"] {
+			t.Errorf("At %s: expected %v, got %v", tt.at, tt.want, result.Results)
+		}
+	}
+	if _, err := repo.ResultsAt(ctx, "1", created.Add(-time.Minute)); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound before the poll existed, got %v", err)
+	}
+}
+
+func TestEventSourcedRebuild(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	store, err := eventstore.NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewEventSourcedRepository(store)
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Yes", "No"}})
+	for i, option := range []string{"Yes", "No", "Yes"} {
+		_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: option}, []string{"aa", "bb", "cc"}[i])
+	}
+	before, _ := repo.GetResults(ctx, "1")
+	audit, _ := repo.GetAuditLog(ctx, "1")
+
+	repo.Rebuild()
+	rebuilt, _ := repo.GetResults(ctx, "1")
+	if rebuilt.Version != before.Version || rebuilt.Results["Yes"] != 2 || rebuilt.Results["No"] != 1 {
+		t.Errorf("Expected the rebuilt tally to match %v, got %v", before.Results, rebuilt.Results)
+	}
+
+	// A new repository over the same log replays the same state
+	_ = repo.Close()
+	store, err = eventstore.NewFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened := NewEventSourcedRepository(store)
+	defer reopened.Close()
+	replayed, _ := reopened.GetAuditLog(ctx, "1")
+	if len(replayed) != len(audit) || replayed[len(replayed)-1].Hash != audit[len(audit)-1].Hash {
+		t.Errorf("Expected the replayed audit log to match the original")
+	}
+	if commitments, _ := reopened.GetCommitments(ctx, "1"); len(commitments) != 3 || commitments[1] != "bb" {
+		t.Errorf("Expected the commitments to be replayed, got %v", commitments)
+	}
+}
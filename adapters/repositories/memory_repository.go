@@ -1,64 +1,105 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
 
 	"polling-system/domain"
 )
 
 type MemoryRepository struct {
-	polls     map[string]*domain.Poll
-	votes     map[string]map[string]int
+	polls       map[string]*domain.Poll
+	votes       map[string]map[string]int
+	commitments map[string][]string
+	audit       map[string][]domain.AuditEntry
+	history     map[string][]domain.TallySnapshot
+	// pollMutex is always taken before voteMutex
 	pollMutex sync.RWMutex
 	voteMutex sync.RWMutex
 }
 
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		polls: make(map[string]*domain.Poll),
-		votes: make(map[string]map[string]int),
+		polls:       make(map[string]*domain.Poll),
+		votes:       make(map[string]map[string]int),
+		commitments: make(map[string][]string),
+		audit:       make(map[string][]domain.AuditEntry),
+		history:     make(map[string][]domain.TallySnapshot),
 	}
 }
 
-func (r *MemoryRepository) CreatePoll(poll domain.Poll) error {
+func (r *MemoryRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	r.pollMutex.Lock()
 	defer r.pollMutex.Unlock()
+	if _, ok := r.polls[poll.ID]; ok {
+		return fmt.Errorf("%w: %s", domain.ErrPollExists, poll.ID)
+	}
 	r.polls[poll.ID] = &poll
 	r.votes[poll.ID] = make(map[string]int)
 	return nil
 }
 
-func (r *MemoryRepository) GetPoll(id string) (domain.Poll, error) {
+func (r *MemoryRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Poll{}, err
+	}
 	r.pollMutex.RLock()
 	defer r.pollMutex.RUnlock()
 	poll, ok := r.polls[id]
 	if !ok {
-		return domain.Poll{}, fmt.Errorf("poll not found")
+		return domain.Poll{}, domain.ErrPollNotFound
 	}
 	return *poll, nil
 }
 
-func (r *MemoryRepository) Vote(vote domain.Vote) error {
-	r.voteMutex.Lock()
-	defer r.voteMutex.Unlock()
+func (r *MemoryRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	return r.voteAt(ctx, vote, commitment, time.Now())
+}
 
+// voteAt records a vote with the given audit timestamp, so replicas that
+// apply the same vote produce the same audit log.
+func (r *MemoryRepository) voteAt(ctx context.Context, vote domain.Vote, commitment string, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	r.pollMutex.RLock()
-	_, ok := r.polls[vote.PollID]
-	r.pollMutex.RUnlock()
+	defer r.pollMutex.RUnlock()
+	if _, ok := r.polls[vote.PollID]; !ok {
+		return domain.ErrPollNotFound
+	}
 
-	if !ok {
-		return fmt.Errorf("poll not found")
+	if err := r.lockVotes(ctx); err != nil {
+		return err
 	}
+	defer r.voteMutex.Unlock()
 
 	if _, ok := r.votes[vote.PollID]; !ok {
 		r.votes[vote.PollID] = make(map[string]int)
 	}
 	r.votes[vote.PollID][vote.Option]++
+
+	var prev *domain.AuditEntry
+	if log := r.audit[vote.PollID]; len(log) > 0 {
+		prev = &log[len(log)-1]
+	}
+	r.audit[vote.PollID] = append(r.audit[vote.PollID], domain.NewAuditEntry(prev, vote, at))
+	r.commitments[vote.PollID] = append(r.commitments[vote.PollID], commitment)
 	return nil
 }
 
-func (r *MemoryRepository) GetResults(pollID string) (domain.PollResult, error) {
+func (r *MemoryRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.PollResult{}, err
+	}
 	r.pollMutex.RLock()
 	defer r.pollMutex.RUnlock()
 	r.voteMutex.RLock()
@@ -66,11 +107,114 @@ func (r *MemoryRepository) GetResults(pollID string) (domain.PollResult, error)
 
 	poll, ok := r.polls[pollID]
 	if !ok {
-		return domain.PollResult{}, fmt.Errorf("poll not found")
+		return domain.PollResult{}, domain.ErrPollNotFound
 	}
 
-	return domain.PollResult{
+	result := domain.PollResult{
 		Poll:    *poll,
-		Results: r.votes[pollID],
-	}, nil
+		Results: maps.Clone(r.votes[pollID]),
+		// Every vote appends exactly one audit entry
+		Version:    uint64(len(r.audit[pollID])),
+		CapturedAt: time.Now(),
+	}
+	result.Poll.Options = slices.Clone(poll.Options)
+	if log := r.audit[pollID]; len(log) > 0 {
+		result.UpdatedAt = log[len(log)-1].Timestamp
+	}
+	return result, nil
+}
+
+func (r *MemoryRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.pollMutex.RLock()
+	defer r.pollMutex.RUnlock()
+	r.voteMutex.RLock()
+	defer r.voteMutex.RUnlock()
+
+	if _, ok := r.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+
+	commitments := make([]string, len(r.commitments[pollID]))
+	copy(commitments, r.commitments[pollID])
+	return commitments, nil
+}
+
+func (r *MemoryRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.pollMutex.RLock()
+	defer r.pollMutex.RUnlock()
+	r.voteMutex.RLock()
+	defer r.voteMutex.RUnlock()
+
+	if _, ok := r.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+
+	entries := make([]domain.AuditEntry, len(r.audit[pollID]))
+	copy(entries, r.audit[pollID])
+	return entries, nil
+}
+
+func (r *MemoryRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.pollMutex.RLock()
+	defer r.pollMutex.RUnlock()
+	if _, ok := r.polls[pollID]; !ok {
+		return domain.ErrPollNotFound
+	}
+
+	if err := r.lockVotes(ctx); err != nil {
+		return err
+	}
+	defer r.voteMutex.Unlock()
+
+	snap.Results = maps.Clone(snap.Results)
+	r.history[pollID] = append(r.history[pollID], snap)
+	return nil
+}
+
+func (r *MemoryRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.pollMutex.RLock()
+	defer r.pollMutex.RUnlock()
+	r.voteMutex.RLock()
+	defer r.voteMutex.RUnlock()
+
+	if _, ok := r.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	return slices.Clone(r.history[pollID]), nil
+}
+
+// Ready reports whether the repository can serve requests. The in-memory
+// store is always available.
+func (r *MemoryRepository) Ready(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// lockVotes takes voteMutex for writing inside a span, so traces show how
+// long a vote queued behind others. If ctx ends while waiting, the lock is
+// released again and the write is abandoned rather than applied late.
+func (r *MemoryRepository) lockVotes(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, span := otel.Tracer("polling-system/adapters/repositories").Start(ctx, "MemoryRepository.voteMutex.Lock")
+	r.voteMutex.Lock()
+	span.End()
+
+	if err := ctx.Err(); err != nil {
+		r.voteMutex.Unlock()
+		return err
+	}
+	return nil
 }
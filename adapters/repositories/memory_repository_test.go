@@ -1,13 +1,21 @@
 package repositories
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"polling-system/adapters/eventstore"
 	"polling-system/domain"
+	"polling-system/ports"
 )
 
 func TestCreatePoll(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
 	poll := domain.Poll{
@@ -16,13 +24,13 @@ func TestCreatePoll(t *testing.T) {
 		Options:  []string{"Option 1", "Option 2"},
 	}
 
-	err := repo.CreatePoll(poll)
+	err := repo.CreatePoll(ctx, poll)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
 	// Verify the poll was created
-	createdPoll, err := repo.GetPoll("1")
+	createdPoll, err := repo.GetPoll(ctx, "1")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -32,6 +40,7 @@ func TestCreatePoll(t *testing.T) {
 }
 
 func TestVote(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
 	// Create a poll first
@@ -40,20 +49,20 @@ func TestVote(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	_ = repo.CreatePoll(poll)
+	_ = repo.CreatePoll(ctx, poll)
 
 	vote := domain.Vote{
 		PollID: "1",
 		Option: "Option 1",
 	}
 
-	err := repo.Vote(vote)
+	err := repo.Vote(ctx, vote, "")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
 	// Verify the vote was recorded
-	results, err := repo.GetResults("1")
+	results, err := repo.GetResults(ctx, "1")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -63,6 +72,7 @@ func TestVote(t *testing.T) {
 }
 
 func TestGetResults(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
 	// Create a poll and add some votes
@@ -71,12 +81,12 @@ func TestGetResults(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	_ = repo.CreatePoll(poll)
-	_ = repo.Vote(domain.Vote{PollID: "1", Option: "Option 1"})
-	_ = repo.Vote(domain.Vote{PollID: "1", Option: "Option 2"})
-	_ = repo.Vote(domain.Vote{PollID: "1", Option: "Option 1"})
+	_ = repo.CreatePoll(ctx, poll)
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"}, "")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
 
-	results, err := repo.GetResults("1")
+	results, err := repo.GetResults(ctx, "1")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -86,6 +96,7 @@ func TestGetResults(t *testing.T) {
 }
 
 func TestVoteNonExistentPoll(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
 	vote := domain.Vote{
@@ -93,24 +104,134 @@ func TestVoteNonExistentPoll(t *testing.T) {
 		Option: "Option 1",
 	}
 
-	err := repo.Vote(vote)
+	err := repo.Vote(ctx, vote, "")
 	if err == nil {
 		t.Error("Expected an error when voting on a non-existent poll, got nil")
 	}
 }
 
 func TestGetResultsNonExistentPoll(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
-	_, err := repo.GetResults("non_existent")
+	_, err := repo.GetResults(ctx, "non_existent")
 	if err == nil {
 		t.Error("Expected an error when getting results for a non-existent poll, got nil")
 	}
 }
 
+func TestVoteRecordsCommitment(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	for _, c := range []string{"aa", "bb", "cc"} {
+		if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, c); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	commitments, err := repo.GetCommitments(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(commitments) != 3 || commitments[1] != "bb" {
+		t.Errorf("Unexpected commitments: %v", commitments)
+	}
+
+	if err := repo.Vote(ctx, domain.Vote{PollID: "non_existent", Option: "Option 1"}, "dd"); err == nil {
+		t.Error("Expected an error when voting on a non-existent poll, got nil")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"}, "")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+
+	entries, err := repo.GetAuditLog(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tallies, err := domain.VerifyAuditLog("1", entries)
+	if err != nil {
+		t.Fatalf("Expected an intact chain, got %v", err)
+	}
+	results, _ := repo.GetResults(ctx, "1")
+	if tallies["Option 1"] != results.Results["Option 1"] || tallies["Option 2"] != results.Results["Option 2"] {
+		t.Errorf("Recomputed tallies %v do not match results %v", tallies, results.Results)
+	}
+
+	// Rewriting a past vote must break the chain
+	entries[1].Option = "Option 1"
+	if _, err := domain.VerifyAuditLog("1", entries); err == nil {
+		t.Error("Expected tampered entry to be detected, got nil")
+	}
+
+	// Dropping an entry must break the chain
+	entries, _ = repo.GetAuditLog(ctx, "1")
+	if _, err := domain.VerifyAuditLog("1", append(entries[:1], entries[2:]...)); err == nil {
+		t.Error("Expected removed entry to be detected, got nil")
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	repo := NewMemoryRepository()
+	_ = repo.CreatePoll(context.Background(), domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, err := repo.GetResults(ctx, "1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := repo.CreatePoll(ctx, domain.Poll{ID: "2"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	results, _ := repo.GetResults(context.Background(), "1")
+	if results.Results["Option 1"] != 0 {
+		t.Errorf("Expected the cancelled vote not to be recorded, got %v", results.Results)
+	}
+}
+
+func TestVoteDeadlineWhileQueued(t *testing.T) {
+	repo := NewMemoryRepository()
+	_ = repo.CreatePoll(context.Background(), domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	// Hold the vote lock so the next vote queues behind it past its deadline
+	repo.voteMutex.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "") }()
+
+	<-ctx.Done()
+	repo.voteMutex.Unlock()
+
+	if err := <-errCh; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	entries, _ := repo.GetAuditLog(context.Background(), "1")
+	if len(entries) != 0 {
+		t.Errorf("Expected the late vote to be abandoned, got %d audit entries", len(entries))
+	}
+}
+
 //
 
 func TestConcurrentVoting(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
 	poll := domain.Poll{
@@ -118,7 +239,7 @@ func TestConcurrentVoting(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	err := repo.CreatePoll(poll)
+	err := repo.CreatePoll(ctx, poll)
 	if err != nil {
 		t.Fatalf("Failed to create poll: %v", err)
 	}
@@ -137,7 +258,7 @@ func TestConcurrentVoting(t *testing.T) {
 				PollID: "1",
 				Option: "Option 1",
 			}
-			if err := repo.Vote(vote); err != nil {
+			if err := repo.Vote(ctx, vote, ""); err != nil {
 				errorChan <- err
 			}
 		}()
@@ -153,7 +274,7 @@ func TestConcurrentVoting(t *testing.T) {
 	}
 
 	// Verify the final vote count
-	results, err := repo.GetResults("1")
+	results, err := repo.GetResults(ctx, "1")
 	if err != nil {
 		t.Fatalf("Failed to get results: %v", err)
 	}
@@ -163,8 +284,134 @@ func TestConcurrentVoting(t *testing.T) {
 	}
 }
 
-/*
+func TestGetResultsSnapshot(t *testing.T) {
+	repos := map[string]ports.PollRepository{
+		"memory":  NewMemoryRepository(),
+		"sharded": NewShardedRepository(4),
+		"events":  NewEventSourcedRepository(eventstore.NewMemory()),
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Option 1", "Option 2"}})
+			_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+
+			before, err := repo.GetResults(ctx, "1")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if before.Version != 1 {
+				t.Errorf("Expected version 1, got %d", before.Version)
+			}
+			if before.CapturedAt.IsZero() {
+				t.Error("Expected a capture time")
+			}
+
+			before.Results["Option 2"] = 100
+			before.Poll.Options[0] = "changed"
+			_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+
+			after, _ := repo.GetResults(ctx, "1")
+			if after.Version != 2 {
+				t.Errorf("Expected version 2, got %d", after.Version)
+			}
+			if after.Results["Option 1"] != 2 || after.Results["Option 2"] != 0 {
+				t.Errorf("Expected the snapshot to be independent of the repository, got %v", after.Results)
+			}
+			if after.Poll.Options[0] != "Option 1" {
+				t.Errorf("Expected the poll options to be copied, got %v", after.Poll.Options)
+			}
+			if before.Results["Option 1"] != 1 {
+				t.Errorf("Expected the earlier snapshot to stay unchanged, got %v", before.Results)
+			}
+		})
+	}
+}
+
+func TestTallyHistory(t *testing.T) {
+	repos := map[string]ports.PollRepository{
+		"memory":  NewMemoryRepository(),
+		"sharded": NewShardedRepository(4),
+		"events":  NewEventSourcedRepository(eventstore.NewMemory()),
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			start := time.Now()
+			_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Option 1", "Option 2"}})
+
+			results := map[string]int{"Option 1": 1, "Option 2": 0}
+			if err := repo.AddTallySnapshot(ctx, "1", domain.TallySnapshot{At: start, Version: 1, Results: results}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			results["Option 1"] = 100
+			_ = repo.AddTallySnapshot(ctx, "1", domain.TallySnapshot{At: start.Add(time.Minute), Version: 2, Results: map[string]int{"Option 1": 1, "Option 2": 1}, Final: true})
+
+			history, err := repo.GetTallyHistory(ctx, "1")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(history) != 2 || history[0].Version != 1 || !history[1].Final {
+				t.Fatalf("Expected both snapshots in order, got %+v", history)
+			}
+			if history[0].Results["Option 1"] != 1 {
+				t.Errorf("Expected the stored snapshot to be independent of the caller's map, got %v", history[0].Results)
+			}
+
+			if err := repo.AddTallySnapshot(ctx, "2", domain.TallySnapshot{At: start}); !errors.Is(err, domain.ErrPollNotFound) {
+				t.Errorf("Expected ErrPollNotFound, got %v", err)
+			}
+			if _, err := repo.GetTallyHistory(ctx, "2"); !errors.Is(err, domain.ErrPollNotFound) {
+				t.Errorf("Expected ErrPollNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetResultsWhileVoting(t *testing.T) {
+	repos := map[string]ports.PollRepository{
+		"memory":  NewMemoryRepository(),
+		"sharded": NewShardedRepository(4),
+		"events":  NewEventSourcedRepository(eventstore.NewMemory()),
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Option 1", "Option 2"}})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 500; i++ {
+					_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: []string{"Option 1", "Option 2"}[i%2]}, "")
+				}
+			}()
+
+			var last uint64
+			for running := true; running; {
+				select {
+				case <-done:
+					running = false
+				default:
+				}
+				result, _ := repo.GetResults(ctx, "1")
+				// encoding reads the map after GetResults returned, which
+				// -race flags if it is still shared with Vote
+				_, _ = json.Marshal(result)
+				if result.Version < last {
+					t.Fatalf("Version went back from %d to %d", last, result.Version)
+				}
+				if total := result.Results["Option 1"] + result.Results["Option 2"]; uint64(total) != result.Version {
+					t.Fatalf("Version %d does not match %d counted votes", result.Version, total)
+				}
+				last = result.Version
+			}
+		})
+	}
+}
+
 func TestCreateExistingPoll(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryRepository()
 
 	poll := domain.Poll{
@@ -173,16 +420,48 @@ func TestCreateExistingPoll(t *testing.T) {
 		Options:  []string{"Option 1", "Option 2"},
 	}
 
-	err := repo.CreatePoll(poll)
+	err := repo.CreatePoll(ctx, poll)
 	if err != nil {
 		t.Errorf("Unexpected error creating poll: %v", err)
 	}
 
 	// Attempt to create the same poll again
-	err = repo.CreatePoll(poll)
-	if err == nil {
-		t.Error("Expected an error when creating a poll with an existing ID, got nil")
+	err = repo.CreatePoll(ctx, poll)
+	if !errors.Is(err, domain.ErrPollExists) {
+		t.Errorf("Expected ErrPollExists when creating a poll with an existing ID, got %v", err)
 	}
 }
 
-*/
+func TestVoteReadAndCreateDoNotDeadlock(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Option 1", "Option 2"}})
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "2", Options: []string{"Option 1", "Option 2"}})
+
+	// A waiting CreatePoll blocks new readers of pollMutex, so locking
+	// in two orders hangs as soon as a vote and a read interleave
+	var wg sync.WaitGroup
+	for _, work := range []func(i int){
+		func(i int) { _ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "") },
+		func(i int) { _ = repo.AddTallySnapshot(ctx, "2", domain.TallySnapshot{}) },
+		func(i int) { _, _ = repo.GetResults(ctx, "1") },
+		func(i int) { _, _ = repo.GetCommitments(ctx, "2") },
+		func(i int) { _ = repo.CreatePoll(ctx, domain.Poll{ID: "p" + strconv.Itoa(i)}) },
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100000; i++ {
+				work(i)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected concurrent votes, reads and poll creation to finish")
+	}
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"polling-system/domain"
+)
+
+// Operations a RaftRepository replicates through the log, or forwards to
+// the leader for a linearizable read.
+const (
+	opCreatePoll     = "create_poll"
+	opVote           = "vote"
+	opAddSnapshot    = "add_tally_snapshot"
+	opGetPoll        = "get_poll"
+	opGetResults     = "get_results"
+	opGetCommitments = "get_commitments"
+	opGetAuditLog    = "get_audit_log"
+	opGetHistory     = "get_tally_history"
+)
+
+type raftCommand struct {
+	Op         string                `json:"op"`
+	PollID     string                `json:"poll_id,omitempty"`
+	Poll       *domain.Poll          `json:"poll,omitempty"`
+	Vote       *domain.Vote          `json:"vote,omitempty"`
+	Commitment string                `json:"commitment,omitempty"`
+	Snapshot   *domain.TallySnapshot `json:"snapshot,omitempty"`
+	// At is fixed by the leader so every replica writes the same audit entry
+	At time.Time `json:"at,omitempty"`
+}
+
+func (c raftCommand) write() bool {
+	return c.Op == opCreatePoll || c.Op == opVote || c.Op == opAddSnapshot
+}
+
+// idempotent reports whether applying cmd twice has the same effect as
+// applying it once, so it may be retried when it is unknown whether it was.
+// Reads are; a vote is because the FSM counts each commitment only once.
+func (c raftCommand) idempotent() bool {
+	return !c.write() || c.Op == opVote && c.Commitment != ""
+}
+
+// raftResult is what raftFSM.Apply returns to the leader's Apply future.
+type raftResult struct {
+	value any
+	err   error
+}
+
+// raftFSM applies committed commands to an in-memory repository. Restore
+// swaps in a fresh one, so readers always see a complete state.
+type raftFSM struct {
+	repo atomic.Pointer[MemoryRepository]
+	// counted holds the poll ID and commitment of every applied vote. Only
+	// Apply and Restore use it, and Raft never runs them concurrently.
+	counted map[string]struct{}
+}
+
+func newRaftFSM() *raftFSM {
+	fsm := &raftFSM{counted: make(map[string]struct{})}
+	fsm.repo.Store(NewMemoryRepository())
+	return fsm
+}
+
+func countedKey(pollID, commitment string) string {
+	return pollID + "\x00" + commitment
+}
+
+func (f *raftFSM) Apply(entry *raft.Log) any {
+	var cmd raftCommand
+	if err := json.Unmarshal(entry.Data, &cmd); err != nil {
+		return raftResult{err: fmt.Errorf("error decoding raft command: %w", err)}
+	}
+	ctx := context.Background()
+	repo := f.repo.Load()
+	switch cmd.Op {
+	case opCreatePoll:
+		return raftResult{err: repo.CreatePoll(ctx, *cmd.Poll)}
+	case opVote:
+		// A vote retried after an ambiguous failure may be in the log twice
+		key := countedKey(cmd.Vote.PollID, cmd.Commitment)
+		if _, ok := f.counted[key]; ok && cmd.Commitment != "" {
+			return raftResult{}
+		}
+		err := repo.voteAt(ctx, *cmd.Vote, cmd.Commitment, cmd.At)
+		if err == nil {
+			f.counted[key] = struct{}{}
+		}
+		return raftResult{err: err}
+	case opAddSnapshot:
+		return raftResult{err: repo.AddTallySnapshot(ctx, cmd.PollID, *cmd.Snapshot)}
+	default:
+		return raftResult{err: fmt.Errorf("unknown raft command %q", cmd.Op)}
+	}
+}
+
+// read serves a read-only command from the local state.
+func (f *raftFSM) read(ctx context.Context, cmd raftCommand) (any, error) {
+	repo := f.repo.Load()
+	switch cmd.Op {
+	case opGetPoll:
+		return repo.GetPoll(ctx, cmd.PollID)
+	case opGetResults:
+		return repo.GetResults(ctx, cmd.PollID)
+	case opGetCommitments:
+		return repo.GetCommitments(ctx, cmd.PollID)
+	case opGetAuditLog:
+		return repo.GetAuditLog(ctx, cmd.PollID)
+	case opGetHistory:
+		return repo.GetTallyHistory(ctx, cmd.PollID)
+	default:
+		return nil, fmt.Errorf("unknown raft command %q", cmd.Op)
+	}
+}
+
+func (f *raftFSM) Snapshot() (raft.FSMSnapshot, error) {
+	return raftSnapshot(f.repo.Load().snapshot()), nil
+}
+
+func (f *raftFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	var snap snapshot
+	if err := json.NewDecoder(rc).Decode(&snap); err != nil {
+		return fmt.Errorf("error decoding raft snapshot: %w", err)
+	}
+	repo := NewMemoryRepository()
+	repo.restore(snap)
+	f.counted = make(map[string]struct{})
+	for pollID, commitments := range snap.Commitments {
+		for _, commitment := range commitments {
+			f.counted[countedKey(pollID, commitment)] = struct{}{}
+		}
+	}
+	f.repo.Store(repo)
+	return nil
+}
+
+type raftSnapshot snapshot
+
+func (s raftSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(snapshot(s)); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s raftSnapshot) Release() {}
@@ -0,0 +1,444 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+
+	"polling-system/domain"
+)
+
+const (
+	raftForwardPath = "/raft/command"
+	// raftTimeout bounds an operation whose context has no deadline.
+	raftTimeout = 10 * time.Second
+)
+
+var ErrNoRaftLeader = errors.New("no raft leader")
+
+// errForwardFailed means a command forwarded to the leader got no answer,
+// although the leader may have received it.
+var errForwardFailed = errors.New("forwarded raft command got no answer")
+
+type RaftPeer struct {
+	ID string `yaml:"id"`
+	// RaftAddr carries the Raft protocol between nodes.
+	RaftAddr string `yaml:"raft_addr"`
+	// ForwardAddr serves the HTTP requests followers forward to the leader.
+	ForwardAddr string `yaml:"forward_addr"`
+}
+
+type RaftConfig struct {
+	// NodeID names this node among Peers.
+	NodeID string
+	// Peers lists every voting node of the cluster, this one included. All
+	// nodes must be started with the same list.
+	Peers []RaftPeer
+	// Secret is shared by every node of the cluster. Followers present it
+	// when they forward a command, and the leader refuses commands without it.
+	Secret string
+	// HeartbeatTimeout tunes failure detection; zero keeps the Raft default.
+	HeartbeatTimeout time.Duration
+	// LogOutput receives Raft's own log; nil means stderr.
+	LogOutput io.Writer
+}
+
+// RaftRepository replicates polls, votes and commitments through a Raft log
+// among 3–5 nodes, so the cluster keeps working while a minority of them is
+// down. Writes and reads on a follower are forwarded to the leader; reads
+// there are linearizable. The Raft log itself is kept in memory: a node
+// that restarts catches up from the others, but losing a majority at once
+// loses the data.
+type RaftRepository struct {
+	self      RaftPeer
+	peers     map[string]RaftPeer
+	raft      *raft.Raft
+	fsm       *raftFSM
+	transport *raft.NetworkTransport
+	forward   *http.Server
+	client    *http.Client
+	secret    string
+	// caughtUpTerm is the last term in which this node, as leader, made sure
+	// its state machine had applied every earlier entry
+	caughtUpTerm atomic.Uint64
+}
+
+func NewRaftRepository(cfg RaftConfig) (*RaftRepository, error) {
+	if cfg.Secret == "" {
+		return nil, errors.New("raft repository needs a cluster secret")
+	}
+	r := &RaftRepository{
+		peers:  make(map[string]RaftPeer, len(cfg.Peers)),
+		fsm:    newRaftFSM(),
+		client: &http.Client{},
+		secret: cfg.Secret,
+	}
+	servers := make([]raft.Server, 0, len(cfg.Peers))
+	for _, peer := range cfg.Peers {
+		r.peers[peer.ID] = peer
+		servers = append(servers, raft.Server{ID: raft.ServerID(peer.ID), Address: raft.ServerAddress(peer.RaftAddr)})
+	}
+	self, ok := r.peers[cfg.NodeID]
+	if !ok {
+		return nil, fmt.Errorf("node %q is not one of the raft peers", cfg.NodeID)
+	}
+	r.self = self
+
+	logOutput := cfg.LogOutput
+	if logOutput == nil {
+		logOutput = os.Stderr
+	}
+	raftCfg := raft.DefaultConfig()
+	raftCfg.LocalID = raft.ServerID(cfg.NodeID)
+	raftCfg.Logger = hclog.New(&hclog.LoggerOptions{Name: "raft." + cfg.NodeID, Level: hclog.Warn, Output: logOutput})
+	if cfg.HeartbeatTimeout > 0 {
+		raftCfg.HeartbeatTimeout = cfg.HeartbeatTimeout
+		raftCfg.ElectionTimeout = cfg.HeartbeatTimeout
+		raftCfg.LeaderLeaseTimeout = cfg.HeartbeatTimeout / 2
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", self.RaftAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving raft address %s: %w", self.RaftAddr, err)
+	}
+	r.transport, err = raft.NewTCPTransport(self.RaftAddr, addr, 3, raftTimeout, logOutput)
+	if err != nil {
+		return nil, fmt.Errorf("error listening for raft on %s: %w", self.RaftAddr, err)
+	}
+	ln, err := net.Listen("tcp", self.ForwardAddr)
+	if err != nil {
+		r.transport.Close()
+		return nil, fmt.Errorf("error listening for forwarded requests on %s: %w", self.ForwardAddr, err)
+	}
+
+	store := raft.NewInmemStore()
+	r.raft, err = raft.NewRaft(raftCfg, r.fsm, store, store, raft.NewInmemSnapshotStore(), r.transport)
+	if err != nil {
+		ln.Close()
+		r.transport.Close()
+		return nil, err
+	}
+	// Every node bootstraps the same configuration; on a node that already
+	// has state this is a no-op
+	err = r.raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+	if err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+		_ = r.Close()
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+raftForwardPath, r.forwardedHandler)
+	r.forward = &http.Server{Handler: mux, ReadHeaderTimeout: raftTimeout}
+	go func() { _ = r.forward.Serve(ln) }()
+	return r, nil
+}
+
+func (r *RaftRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	return r.execute(ctx, raftCommand{Op: opCreatePoll, Poll: &poll}, nil)
+}
+
+func (r *RaftRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	var poll domain.Poll
+	err := r.execute(ctx, raftCommand{Op: opGetPoll, PollID: id}, &poll)
+	return poll, err
+}
+
+func (r *RaftRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	return r.execute(ctx, raftCommand{Op: opVote, Vote: &vote, Commitment: commitment}, nil)
+}
+
+func (r *RaftRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	var result domain.PollResult
+	err := r.execute(ctx, raftCommand{Op: opGetResults, PollID: pollID}, &result)
+	return result, err
+}
+
+func (r *RaftRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	var commitments []string
+	err := r.execute(ctx, raftCommand{Op: opGetCommitments, PollID: pollID}, &commitments)
+	return commitments, err
+}
+
+func (r *RaftRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	err := r.execute(ctx, raftCommand{Op: opGetAuditLog, PollID: pollID}, &entries)
+	return entries, err
+}
+
+func (r *RaftRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	return r.execute(ctx, raftCommand{Op: opAddSnapshot, PollID: pollID, Snapshot: &snap}, nil)
+}
+
+func (r *RaftRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	var history []domain.TallySnapshot
+	err := r.execute(ctx, raftCommand{Op: opGetHistory, PollID: pollID}, &history)
+	return history, err
+}
+
+// Ready reports whether the cluster has a leader to serve requests.
+func (r *RaftRepository) Ready(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, id := r.raft.LeaderWithID(); id == "" {
+		return ErrNoRaftLeader
+	}
+	return nil
+}
+
+// Leader reports whether this node currently leads the cluster.
+func (r *RaftRepository) Leader() bool {
+	return r.raft.State() == raft.Leader
+}
+
+// Close stops serving forwarded requests and leaves the cluster.
+func (r *RaftRepository) Close() error {
+	var errs []error
+	if r.forward != nil {
+		errs = append(errs, r.forward.Close())
+	}
+	errs = append(errs, r.raft.Shutdown().Error(), r.transport.Close())
+	return errors.Join(errs...)
+}
+
+// execute runs cmd on the leader, locally or by forwarding it, and decodes
+// the result into out. It retries while the cluster elects a leader, and
+// after failures that leave it unknown whether cmd was applied only if cmd
+// is idempotent.
+func (r *RaftRepository) execute(ctx context.Context, cmd raftCommand, out any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, raftTimeout)
+		defer cancel()
+	}
+
+	for {
+		var value any
+		var err error
+		if _, id := r.raft.LeaderWithID(); id == raft.ServerID(r.self.ID) {
+			value, err = r.executeLocal(ctx, cmd)
+		} else if id != "" {
+			value, err = r.forwardTo(ctx, r.peers[string(id)], cmd)
+		} else {
+			err = ErrNoRaftLeader
+		}
+		if err == nil {
+			return decodeRaftValue(value, out)
+		}
+		// A write that may already have been applied is only sent again if
+		// applying it twice is harmless
+		if !retryableRaftError(err) && !(ambiguousRaftError(err) && cmd.idempotent()) {
+			return err
+		}
+
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		}
+	}
+}
+
+// executeLocal applies a write through the log, or serves a read after
+// confirming this node still leads and has applied everything committed.
+func (r *RaftRepository) executeLocal(ctx context.Context, cmd raftCommand) (any, error) {
+	timeout, err := raftTimeoutFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cmd.write() {
+		if err := r.raft.VerifyLeader().Error(); err != nil {
+			return nil, err
+		}
+		if term := r.raft.CurrentTerm(); r.caughtUpTerm.Load() != term {
+			if err := r.raft.Barrier(timeout).Error(); err != nil {
+				return nil, err
+			}
+			r.caughtUpTerm.Store(term)
+		}
+		return r.fsm.read(ctx, cmd)
+	}
+
+	if cmd.Op == opVote && cmd.At.IsZero() {
+		cmd.At = time.Now()
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	future := r.raft.Apply(data, timeout)
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+	result := future.Response().(raftResult)
+	return result.value, result.err
+}
+
+// raftTimeoutFor is how long Raft may take on behalf of ctx. Forwarded
+// commands arrive without a deadline, and Raft waits forever on a timeout
+// of zero or less, so they get raftTimeout.
+func raftTimeoutFor(ctx context.Context) (time.Duration, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return raftTimeout, nil
+	}
+	if timeout := time.Until(deadline); timeout > 0 {
+		return timeout, nil
+	}
+	return 0, context.DeadlineExceeded
+}
+
+// raftResponse is the wire form of a forwarded command's outcome.
+type raftResponse struct {
+	Value json.RawMessage `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Code  string          `json:"code,omitempty"`
+}
+
+const (
+	codeNotLeader     = "not_leader"
+	codeLeaderLost    = "leadership_lost"
+	codePollNotFound  = "poll_not_found"
+	codeInvalidOption = "invalid_option"
+	codePollClosed    = "poll_closed"
+	codePollExists    = "poll_exists"
+)
+
+func (r *RaftRepository) forwardTo(ctx context.Context, leader RaftPeer, cmd raftCommand) (any, error) {
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+leader.ForwardAddr+raftForwardPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.secret)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		// Only a failed dial proves the leader never saw the command
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, fmt.Errorf("%w: %v", raft.ErrNotLeader, err)
+		}
+		return nil, fmt.Errorf("%w: %v", errForwardFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("raft leader %s rejected this node's cluster secret", leader.ID)
+	}
+
+	var out raftResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("%w: error decoding response from raft leader %s: %v", errForwardFailed, leader.ID, err)
+	}
+	switch out.Code {
+	case "":
+		return out.Value, nil
+	case codeNotLeader:
+		return nil, raft.ErrNotLeader
+	case codeLeaderLost:
+		return nil, raft.ErrLeadershipLost
+	case codePollNotFound:
+		return nil, domain.ErrPollNotFound
+	case codeInvalidOption:
+		return nil, domain.ErrInvalidOption
+	case codePollClosed:
+		return nil, domain.ErrPollClosed
+	case codePollExists:
+		return nil, domain.ErrPollExists
+	default:
+		return nil, errors.New(out.Error)
+	}
+}
+
+// forwardedHandler executes a command a follower forwarded. It never
+// forwards again, so a stale view of the leader cannot cause loops.
+func (r *RaftRepository) forwardedHandler(w http.ResponseWriter, req *http.Request) {
+	provided, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(r.secret)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var cmd raftCommand
+	if err := json.NewDecoder(req.Body).Decode(&cmd); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out raftResponse
+	value, err := r.executeLocal(req.Context(), cmd)
+	if err == nil {
+		out.Value, err = json.Marshal(value)
+	}
+	if err != nil {
+		out.Error = err.Error()
+		switch {
+		case retryableRaftError(err):
+			out.Code = codeNotLeader
+		case errors.Is(err, raft.ErrLeadershipLost):
+			out.Code = codeLeaderLost
+		case errors.Is(err, domain.ErrPollNotFound):
+			out.Code = codePollNotFound
+		case errors.Is(err, domain.ErrInvalidOption):
+			out.Code = codeInvalidOption
+		case errors.Is(err, domain.ErrPollClosed):
+			out.Code = codePollClosed
+		case errors.Is(err, domain.ErrPollExists):
+			out.Code = codePollExists
+		default:
+			out.Code = "error"
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// retryableRaftError reports errors that go away once a leader is known and
+// that mean the command was never applied.
+func retryableRaftError(err error) bool {
+	return errors.Is(err, raft.ErrNotLeader) ||
+		errors.Is(err, raft.ErrLeadershipTransferInProgress) ||
+		errors.Is(err, ErrNoRaftLeader)
+}
+
+// ambiguousRaftError reports errors after which the command may or may not
+// have been applied: the leader lost its leadership while the entry was in
+// flight, or a forwarded request failed after it was sent.
+func ambiguousRaftError(err error) bool {
+	return errors.Is(err, raft.ErrLeadershipLost) || errors.Is(err, errForwardFailed)
+}
+
+// decodeRaftValue copies a local or forwarded result into out.
+func decodeRaftValue(value any, out any) error {
+	if out == nil {
+		return nil
+	}
+	data, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, out)
+}
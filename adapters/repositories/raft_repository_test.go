@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"polling-system/domain"
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected a free port, got %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// startRaftCluster runs size nodes on loopback and waits for a leader.
+func startRaftCluster(t *testing.T, size int) []*RaftRepository {
+	t.Helper()
+	peers := make([]RaftPeer, size)
+	for i := range peers {
+		peers[i] = RaftPeer{ID: "node" + strconv.Itoa(i), RaftAddr: freeAddr(t), ForwardAddr: freeAddr(t)}
+	}
+	nodes := make([]*RaftRepository, size)
+	for i := range nodes {
+		node, err := NewRaftRepository(RaftConfig{
+			NodeID:           peers[i].ID,
+			Peers:            peers,
+			Secret:           "cluster-secret",
+			HeartbeatTimeout: 200 * time.Millisecond,
+			LogOutput:        io.Discard,
+		})
+		if err != nil {
+			t.Fatalf("Expected node %d to start, got %v", i, err)
+		}
+		nodes[i] = node
+		t.Cleanup(func() { _ = node.Close() })
+	}
+	waitForRaftLeader(t, nodes)
+	return nodes
+}
+
+func waitForRaftLeader(t *testing.T, nodes []*RaftRepository) *RaftRepository {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, node := range nodes {
+			if node.Leader() {
+				return node
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Expected the cluster to elect a leader")
+	return nil
+}
+
+func TestRaftRepositoryReplicates(t *testing.T) {
+	ctx := context.Background()
+	nodes := startRaftCluster(t, 3)
+
+	var follower *RaftRepository
+	for _, node := range nodes {
+		if !node.Leader() {
+			follower = node
+			break
+		}
+	}
+	poll := domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}}
+	if err := follower.CreatePoll(ctx, poll); err != nil {
+		t.Fatalf("Expected a follower to forward the poll, got %v", err)
+	}
+
+	for i, node := range nodes {
+		if err := node.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "c"+strconv.Itoa(i)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+	if err := follower.Vote(ctx, domain.Vote{PollID: "2", Option: "Option 1"}, ""); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound, got %v", err)
+	}
+	if _, err := follower.GetResults(ctx, "2"); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound, got %v", err)
+	}
+
+	// Reads are linearizable, so every node sees all three votes at once
+	for i, node := range nodes {
+		result, err := node.GetResults(ctx, "1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Results["Option 1"] != 3 || result.Version != 3 {
+			t.Errorf("Expected node %d to count 3 votes at version 3, got %v at %d", i, result.Results, result.Version)
+		}
+	}
+
+	entries, err := follower.GetAuditLog(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := domain.VerifyAuditLog("1", entries); err != nil {
+		t.Errorf("Expected a valid audit log, got %v", err)
+	}
+	if commitments, err := follower.GetCommitments(ctx, "1"); err != nil || len(commitments) != 3 {
+		t.Errorf("Expected the 3 commitments recorded with the votes, got %v, %v", commitments, err)
+	}
+	if err := follower.AddTallySnapshot(ctx, "1", domain.NewTallySnapshot(domain.PollResult{Results: map[string]int{"Option 1": 3}, Version: 3}, time.Now())); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	for i, node := range nodes {
+		if history, err := node.GetTallyHistory(ctx, "1"); err != nil || len(history) != 1 || history[0].Results["Option 1"] != 3 {
+			t.Errorf("Expected node %d to see the replicated snapshot, got %+v, %v", i, history, err)
+		}
+	}
+}
+
+func TestRaftRepositoryRejectsUnauthenticatedForwards(t *testing.T) {
+	ctx := context.Background()
+	nodes := startRaftCluster(t, 3)
+	leader := waitForRaftLeader(t, nodes)
+	peer := leader.self
+
+	body, _ := json.Marshal(raftCommand{Op: opCreatePoll, Poll: &domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}}})
+	for _, auth := range []string{"", "Bearer wrong-secret", "cluster-secret"} {
+		req, err := http.NewRequest(http.MethodPost, "http://"+peer.ForwardAddr+raftForwardPath, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected a response, got %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for Authorization %q, got %d", auth, resp.StatusCode)
+		}
+	}
+	if _, err := leader.GetPoll(ctx, "1"); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected the rejected command not to be applied, got %v", err)
+	}
+}
+
+func TestRaftRepositorySurvivesLosingLeader(t *testing.T) {
+	ctx := context.Background()
+	nodes := startRaftCluster(t, 3)
+
+	leader := waitForRaftLeader(t, nodes)
+	if err := leader.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Yes", "No"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := leader.Vote(ctx, domain.Vote{PollID: "1", Option: "Yes"}, ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = leader.Close()
+
+	var survivors []*RaftRepository
+	for _, node := range nodes {
+		if node != leader {
+			survivors = append(survivors, node)
+		}
+	}
+	waitForRaftLeader(t, survivors)
+
+	for _, node := range survivors {
+		if err := node.Vote(ctx, domain.Vote{PollID: "1", Option: "No"}, ""); err != nil {
+			t.Errorf("Expected votes to keep working, got %v", err)
+		}
+	}
+	for i, node := range survivors {
+		result, err := node.GetResults(ctx, "1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Results["Yes"] != 1 || result.Results["No"] != 2 {
+			t.Errorf("Expected survivor %d to keep every vote, got %v", i, result.Results)
+		}
+	}
+}
+
+func TestRaftFSMCountsRetriedVoteOnce(t *testing.T) {
+	ctx := context.Background()
+	fsm := newRaftFSM()
+	apply := func(cmd raftCommand) error {
+		data, _ := json.Marshal(cmd)
+		return fsm.Apply(&raft.Log{Data: data}).(raftResult).err
+	}
+
+	_ = apply(raftCommand{Op: opCreatePoll, Poll: &domain.Poll{ID: "1", Options: []string{"Yes", "No"}}})
+	vote := raftCommand{Op: opVote, Vote: &domain.Vote{PollID: "1", Option: "Yes"}, Commitment: "c0", At: time.Now()}
+	for i := 0; i < 2; i++ {
+		if err := apply(vote); err != nil {
+			t.Fatalf("Expected no error applying the vote, got %v", err)
+		}
+	}
+	if result, _ := fsm.repo.Load().GetResults(ctx, "1"); result.Results["Yes"] != 1 {
+		t.Errorf("Expected a vote in the log twice to count once, got %v", result.Results)
+	}
+
+	// The commitments restored from a snapshot are remembered too
+	snap, _ := fsm.Snapshot()
+	sink := &bufferSink{}
+	_ = snap.Persist(sink)
+	restored := newRaftFSM()
+	if err := restored.Restore(io.NopCloser(&sink.Buffer)); err != nil {
+		t.Fatalf("Expected no error restoring, got %v", err)
+	}
+	fsm = restored
+	_ = apply(vote)
+	if result, _ := fsm.repo.Load().GetResults(ctx, "1"); result.Results["Yes"] != 1 {
+		t.Errorf("Expected the restored FSM to skip the vote again, got %v", result.Results)
+	}
+}
+
+type bufferSink struct {
+	bytes.Buffer
+}
+
+func (s *bufferSink) ID() string    { return "test" }
+func (s *bufferSink) Cancel() error { return nil }
+func (s *bufferSink) Close() error  { return nil }
+
+func TestRaftTimeoutFor(t *testing.T) {
+	if timeout, err := raftTimeoutFor(context.Background()); err != nil || timeout != raftTimeout {
+		t.Errorf("Expected forwarded commands without a deadline to get %v, got %v, %v", raftTimeout, timeout, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if timeout, err := raftTimeoutFor(ctx); err != nil || timeout <= raftTimeout || timeout > time.Minute {
+		t.Errorf("Expected the context's deadline to be kept, got %v, %v", timeout, err)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := raftTimeoutFor(expired); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded for a passed deadline, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"polling-system/domain"
+)
+
+const DefaultStripes = 64
+
+// ShardedRepository spreads polls over lock stripes and counts votes with
+// per-option atomic counters, so votes on different polls never contend on
+// a shared lock. Votes on the same poll only serialize for the append to
+// its audit log.
+type ShardedRepository struct {
+	stripes []stripe
+}
+
+type stripe struct {
+	mu    sync.RWMutex
+	polls map[string]*pollShard
+}
+
+type pollShard struct {
+	poll     domain.Poll
+	counters map[string]*atomic.Int64 // fixed at creation, read without locks
+	// seq is odd while a vote is being counted; half of it is the version
+	seq     atomic.Uint64
+	updated atomic.Int64 // UnixNano of the latest vote
+
+	mu          sync.Mutex
+	commitments []string
+	audit       []domain.AuditEntry
+	history     []domain.TallySnapshot
+}
+
+func NewShardedRepository(stripes int) *ShardedRepository {
+	if stripes <= 0 {
+		stripes = DefaultStripes
+	}
+	r := &ShardedRepository{stripes: make([]stripe, stripes)}
+	for i := range r.stripes {
+		r.stripes[i].polls = make(map[string]*pollShard)
+	}
+	return r
+}
+
+func (r *ShardedRepository) stripeFor(pollID string) *stripe {
+	h := fnv.New32a()
+	h.Write([]byte(pollID))
+	return &r.stripes[h.Sum32()%uint32(len(r.stripes))]
+}
+
+func (r *ShardedRepository) shard(ctx context.Context, pollID string) (*pollShard, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := r.stripeFor(pollID)
+	s.mu.RLock()
+	p, ok := s.polls[pollID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	return p, nil
+}
+
+func (r *ShardedRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p := &pollShard{poll: poll, counters: make(map[string]*atomic.Int64, len(poll.Options))}
+	for _, option := range poll.Options {
+		p.counters[option] = new(atomic.Int64)
+	}
+
+	s := r.stripeFor(poll.ID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.polls[poll.ID]; ok {
+		return fmt.Errorf("%w: %s", domain.ErrPollExists, poll.ID)
+	}
+	s.polls[poll.ID] = p
+	return nil
+}
+
+func (r *ShardedRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	p, err := r.shard(ctx, id)
+	if err != nil {
+		return domain.Poll{}, err
+	}
+	return p.poll, nil
+}
+
+func (r *ShardedRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	p, err := r.shard(ctx, vote.PollID)
+	if err != nil {
+		return err
+	}
+	counter, ok := p.counters[vote.Option]
+	if !ok {
+		return domain.ErrInvalidOption
+	}
+
+	if err := p.lock(ctx); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	var prev *domain.AuditEntry
+	if len(p.audit) > 0 {
+		prev = &p.audit[len(p.audit)-1]
+	}
+	entry := domain.NewAuditEntry(prev, vote, time.Now())
+	p.audit = append(p.audit, entry)
+	p.commitments = append(p.commitments, commitment)
+	p.seq.Add(1)
+	counter.Add(1)
+	p.updated.Store(entry.Timestamp.UnixNano())
+	p.seq.Add(1)
+	return nil
+}
+
+func (r *ShardedRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	p, err := r.shard(ctx, pollID)
+	if err != nil {
+		return domain.PollResult{}, err
+	}
+	result := domain.PollResult{Poll: p.poll}
+	result.Poll.Options = slices.Clone(p.poll.Options)
+
+	// Retry until no vote was counted while reading, so the counts match
+	// the version without taking the poll's lock
+	for {
+		seq := p.seq.Load()
+		if seq%2 == 1 {
+			runtime.Gosched()
+			continue
+		}
+		result.Results = make(map[string]int, len(p.counters))
+		for option, counter := range p.counters {
+			if n := counter.Load(); n > 0 {
+				result.Results[option] = int(n)
+			}
+		}
+		updated := p.updated.Load()
+		if p.seq.Load() == seq {
+			result.Version = seq / 2
+			if updated != 0 {
+				result.UpdatedAt = time.Unix(0, updated).UTC()
+			}
+			break
+		}
+	}
+	result.CapturedAt = time.Now()
+	return result, nil
+}
+
+func (r *ShardedRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	p, err := r.shard(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.commitments...), nil
+}
+
+func (r *ShardedRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	p, err := r.shard(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.AuditEntry(nil), p.audit...), nil
+}
+
+func (r *ShardedRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	p, err := r.shard(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if err := p.lock(ctx); err != nil {
+		return err
+	}
+	defer p.mu.Unlock()
+
+	snap.Results = maps.Clone(snap.Results)
+	p.history = append(p.history, snap)
+	return nil
+}
+
+func (r *ShardedRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	p, err := r.shard(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.TallySnapshot(nil), p.history...), nil
+}
+
+func (r *ShardedRepository) Ready(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// lock takes the poll's mutex inside a span and abandons the write if ctx
+// ended while waiting, like MemoryRepository.lockVotes.
+func (p *pollShard) lock(ctx context.Context) error {
+	_, span := otel.Tracer("polling-system/adapters/repositories").Start(ctx, "ShardedRepository.pollMutex.Lock")
+	p.mu.Lock()
+	span.End()
+
+	if err := ctx.Err(); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+func TestShardedRepositoryVote(t *testing.T) {
+	ctx := context.Background()
+	repo := NewShardedRepository(4)
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, ""); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 3"}, ""); !errors.Is(err, domain.ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+	if err := repo.Vote(ctx, domain.Vote{PollID: "2", Option: "Option 1"}, ""); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound, got %v", err)
+	}
+
+	result, err := repo.GetResults(ctx, "1")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result.Results["Option 1"] != 1 || len(result.Results) != 1 {
+		t.Errorf("Expected one vote for Option 1, got %v", result.Results)
+	}
+
+	entries, _ := repo.GetAuditLog(ctx, "1")
+	tally, err := domain.VerifyAuditLog("1", entries)
+	if err != nil {
+		t.Errorf("Expected a valid audit log, got %v", err)
+	}
+	if tally["Option 1"] != 1 {
+		t.Errorf("Expected the audit log to count one vote, got %v", tally)
+	}
+
+	if err := repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Option 1"}}); !errors.Is(err, domain.ErrPollExists) {
+		t.Errorf("Expected ErrPollExists, got %v", err)
+	}
+	if result, _ := repo.GetResults(ctx, "1"); result.Results["Option 1"] != 1 {
+		t.Errorf("Expected a rejected create to keep the votes, got %v", result.Results)
+	}
+}
+
+func TestShardedRepositoryConcurrentVotes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewShardedRepository(8)
+
+	const polls, voters = 16, 50
+	for p := 0; p < polls; p++ {
+		_ = repo.CreatePoll(ctx, domain.Poll{ID: strconv.Itoa(p), Options: []string{"Yes", "No"}})
+	}
+
+	var wg sync.WaitGroup
+	for p := 0; p < polls; p++ {
+		for v := 0; v < voters; v++ {
+			wg.Add(1)
+			go func(pollID string) {
+				defer wg.Done()
+				_ = repo.Vote(ctx, domain.Vote{PollID: pollID, Option: "Yes"}, "")
+			}(strconv.Itoa(p))
+		}
+	}
+	wg.Wait()
+
+	for p := 0; p < polls; p++ {
+		id := strconv.Itoa(p)
+		result, _ := repo.GetResults(ctx, id)
+		if result.Results["Yes"] != voters {
+			t.Errorf("Poll %s: expected %d votes, got %d", id, voters, result.Results["Yes"])
+		}
+		entries, _ := repo.GetAuditLog(ctx, id)
+		if _, err := domain.VerifyAuditLog(id, entries); err != nil {
+			t.Errorf("Poll %s: expected a valid audit log, got %v", id, err)
+		}
+	}
+}
+
+func TestShardedRepositoryCommitments(t *testing.T) {
+	ctx := context.Background()
+	repo := NewShardedRepository(0)
+
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Yes"}, "c0"); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound, got %v", err)
+	}
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Yes", "No"}})
+	for _, c := range []string{"c0", "c1"} {
+		if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Yes"}, c); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Maybe"}, "c2"); !errors.Is(err, domain.ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+
+	commitments, _ := repo.GetCommitments(ctx, "1")
+	if len(commitments) != 2 {
+		t.Errorf("Expected a commitment per counted vote, got %v", commitments)
+	}
+	commitments[0] = "changed"
+	if again, _ := repo.GetCommitments(ctx, "1"); again[0] != "c0" {
+		t.Errorf("Expected GetCommitments to return a copy, got %v", again)
+	}
+}
+
+func TestShardedRepositoryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := NewShardedRepository(4)
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Options: []string{"Yes", "No"}})
+	cancel()
+
+	if err := repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Yes"}, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := repo.Ready(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	result, _ := repo.GetResults(context.Background(), "1")
+	if len(result.Results) != 0 {
+		t.Errorf("Expected no votes after cancellation, got %v", result.Results)
+	}
+}
+
+// Run with -cpu 1,2,4,8 to compare how both repositories scale when votes
+// go to many different polls, e.g.
+//
+//	go test ./adapters/repositories -run '^$' -bench Vote -cpu 1,2,4,8
+func BenchmarkVoteManyPolls(b *testing.B) {
+	benchmarkRepositories(b, 1024)
+}
+
+func BenchmarkVoteSinglePoll(b *testing.B) {
+	benchmarkRepositories(b, 1)
+}
+
+func benchmarkRepositories(b *testing.B, polls int) {
+	b.Run("memory", func(b *testing.B) {
+		benchmarkVote(b, NewMemoryRepository(), polls)
+	})
+	b.Run("sharded", func(b *testing.B) {
+		benchmarkVote(b, NewShardedRepository(DefaultStripes), polls)
+	})
+}
+
+func benchmarkVote(b *testing.B, repo ports.PollRepository, polls int) {
+	ctx := context.Background()
+	ids := make([]string, polls)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+		_ = repo.CreatePoll(ctx, domain.Poll{ID: ids[i], Options: []string{"Yes", "No"}})
+	}
+
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// each goroutine starts on its own poll and walks through the rest
+		i := int(next.Add(1))
+		for pb.Next() {
+			if err := repo.Vote(ctx, domain.Vote{PollID: ids[i%polls], Option: "Yes"}, ""); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
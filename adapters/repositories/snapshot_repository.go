@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"polling-system/domain"
+)
+
+// SnapshotRepository is a MemoryRepository that is loaded from a JSON file on
+// start and written back to it on Flush.
+type SnapshotRepository struct {
+	*MemoryRepository
+	path string
+}
+
+type snapshot struct {
+	Polls       []domain.Poll                     `json:"polls"`
+	Votes       map[string]map[string]int         `json:"votes"`
+	Commitments map[string][]string               `json:"commitments"`
+	Audit       map[string][]domain.AuditEntry    `json:"audit"`
+	History     map[string][]domain.TallySnapshot `json:"history,omitempty"`
+}
+
+func NewSnapshotRepository(path string) (*SnapshotRepository, error) {
+	repo := &SnapshotRepository{MemoryRepository: NewMemoryRepository(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return repo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("error reading snapshot %s: %w", path, err)
+	}
+	repo.restore(snap)
+	return repo, nil
+}
+
+// Ready fails while the directory the snapshot is flushed to is unreachable.
+func (r *SnapshotRepository) Ready(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Dir(r.path)); err != nil {
+		return fmt.Errorf("snapshot directory unavailable: %w", err)
+	}
+	return nil
+}
+
+// Flush atomically replaces the snapshot file with the current state.
+func (r *SnapshotRepository) Flush() error {
+	data, err := json.Marshal(r.snapshot())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+func (r *MemoryRepository) snapshot() snapshot {
+	r.pollMutex.RLock()
+	defer r.pollMutex.RUnlock()
+	r.voteMutex.RLock()
+	defer r.voteMutex.RUnlock()
+
+	snap := snapshot{
+		Votes:       make(map[string]map[string]int, len(r.votes)),
+		Commitments: make(map[string][]string, len(r.commitments)),
+		Audit:       make(map[string][]domain.AuditEntry, len(r.audit)),
+		History:     make(map[string][]domain.TallySnapshot, len(r.history)),
+	}
+	for _, poll := range r.polls {
+		snap.Polls = append(snap.Polls, *poll)
+	}
+	for pollID, votes := range r.votes {
+		snap.Votes[pollID] = make(map[string]int, len(votes))
+		for option, count := range votes {
+			snap.Votes[pollID][option] = count
+		}
+	}
+	for pollID, commitments := range r.commitments {
+		snap.Commitments[pollID] = append([]string(nil), commitments...)
+	}
+	for pollID, entries := range r.audit {
+		snap.Audit[pollID] = append([]domain.AuditEntry(nil), entries...)
+	}
+	for pollID, history := range r.history {
+		snap.History[pollID] = append([]domain.TallySnapshot(nil), history...)
+	}
+	return snap
+}
+
+func (r *MemoryRepository) restore(snap snapshot) {
+	r.pollMutex.Lock()
+	defer r.pollMutex.Unlock()
+	r.voteMutex.Lock()
+	defer r.voteMutex.Unlock()
+
+	for i := range snap.Polls {
+		poll := snap.Polls[i]
+		r.polls[poll.ID] = &poll
+		r.votes[poll.ID] = make(map[string]int)
+	}
+	for pollID, votes := range snap.Votes {
+		r.votes[pollID] = votes
+	}
+	for pollID, commitments := range snap.Commitments {
+		r.commitments[pollID] = commitments
+	}
+	for pollID, entries := range snap.Audit {
+		r.audit[pollID] = entries
+	}
+	for pollID, history := range snap.History {
+		r.history[pollID] = history
+	}
+}
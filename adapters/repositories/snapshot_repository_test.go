@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"polling-system/domain"
+)
+
+func TestSnapshotRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "polls.json")
+
+	repo, err := NewSnapshotRepository(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening a missing snapshot, got %v", err)
+	}
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "aa")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"}, "bb")
+	_ = repo.AddTallySnapshot(ctx, "1", domain.TallySnapshot{Version: 2, Results: map[string]int{"Option 1": 1, "Option 2": 1}})
+
+	if err := repo.Flush(); err != nil {
+		t.Fatalf("Expected no error flushing, got %v", err)
+	}
+
+	reopened, err := NewSnapshotRepository(path)
+	if err != nil {
+		t.Fatalf("Expected no error reopening, got %v", err)
+	}
+
+	results, err := reopened.GetResults(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if results.Poll.Question != "Test question?" || results.Results["Option 1"] != 1 || results.Results["Option 2"] != 1 {
+		t.Errorf("Unexpected results after reload: %+v", results)
+	}
+
+	entries, _ := reopened.GetAuditLog(ctx, "1")
+	if _, err := domain.VerifyAuditLog("1", entries); err != nil || len(entries) != 2 {
+		t.Errorf("Expected audit log to survive reload intact, got %d entries: %v", len(entries), err)
+	}
+
+	if history, _ := reopened.GetTallyHistory(ctx, "1"); len(history) != 1 || history[0].Version != 2 {
+		t.Errorf("Expected the tally history to survive reload, got %+v", history)
+	}
+
+	// New votes keep extending the restored chain
+	_ = reopened.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "cc")
+	entries, _ = reopened.GetAuditLog(ctx, "1")
+	if _, err := domain.VerifyAuditLog("1", entries); err != nil {
+		t.Errorf("Expected chain to continue after reload, got %v", err)
+	}
+
+	commitments, _ := reopened.GetCommitments(ctx, "1")
+	if len(commitments) != 3 || commitments[0] != "aa" {
+		t.Errorf("Expected the commitments to survive reload, got %v", commitments)
+	}
+}
+
+func TestSnapshotRepositoryReady(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewSnapshotRepository(filepath.Join(dir, "polls.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := repo.Ready(context.Background()); err != nil {
+		t.Errorf("Expected a reachable snapshot directory to be ready, got %v", err)
+	}
+
+	repo.path = filepath.Join(dir, "missing", "polls.json")
+	if err := repo.Ready(context.Background()); err == nil {
+		t.Error("Expected an unreachable snapshot directory to fail readiness, got nil")
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	MaxHeaderBytes  int
+	TLSCertFile     string
+	TLSKeyFile      string
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long the server keeps serving after shutdown starts
+	// while reporting itself as draining, giving load balancers time to
+	// stop routing new requests to it.
+	DrainDelay time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:            ":8080",
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		MaxHeaderBytes:  http.DefaultMaxHeaderBytes,
+		ShutdownTimeout: 15 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Addr == "" {
+		return errors.New("listen address must not be empty")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("TLS certificate and key must be set together")
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 || c.ShutdownTimeout < 0 || c.DrainDelay < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	if c.MaxHeaderBytes < 0 {
+		return errors.New("max header bytes must not be negative")
+	}
+	return nil
+}
+
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
+type Server struct {
+	cfg        Config
+	httpServer *http.Server
+	draining   atomic.Bool
+}
+
+func New(cfg Config, handler http.Handler) *Server {
+	return &Server{
+		cfg: cfg,
+		httpServer: &http.Server{
+			Addr:           cfg.Addr,
+			Handler:        handler,
+			ReadTimeout:    cfg.ReadTimeout,
+			WriteTimeout:   cfg.WriteTimeout,
+			IdleTimeout:    cfg.IdleTimeout,
+			MaxHeaderBytes: cfg.MaxHeaderBytes,
+		},
+	}
+}
+
+// OnShutdown registers f to run as soon as shutdown starts, before in-flight
+// requests are drained. Long-lived handlers such as SSE streams use it to
+// finish their responses so the drain can complete.
+func (s *Server) OnShutdown(f func()) {
+	s.httpServer.RegisterOnShutdown(f)
+}
+
+// Draining reports whether shutdown has started.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve accepts connections on ln until ctx is cancelled. It then reports
+// itself as draining for DrainDelay, stops accepting new connections and
+// waits up to ShutdownTimeout for in-flight requests to finish.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if s.cfg.TLSEnabled() {
+			errCh <- s.httpServer.ServeTLS(ln, s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		} else {
+			errCh <- s.httpServer.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	if s.cfg.DrainDelay > 0 {
+		select {
+		case <-time.After(s.cfg.DrainDelay):
+		case err := <-errCh:
+			return err
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		_ = s.httpServer.Close()
+		return fmt.Errorf("error draining connections: %w", err)
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Addr = ln.Addr().String()
+	srv := New(cfg, handler)
+
+	shutdownCalled := make(chan struct{})
+	srv.OnShutdown(func() { close(shutdownCalled) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	type response struct {
+		body string
+		err  error
+	}
+	respCh := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			respCh <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		respCh <- response{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	resp := <-respCh
+	if resp.err != nil || resp.body != "done" {
+		t.Errorf("Expected in-flight request to complete, got %q, %v", resp.body, resp.err)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+
+	select {
+	case <-shutdownCalled:
+	default:
+		t.Error("Expected shutdown hook to be called")
+	}
+
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Error("Expected new connections to be refused after shutdown")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v", err)
+	}
+
+	cfg.TLSCertFile = "cert.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error when the TLS key is missing, got nil")
+	}
+
+	cfg = DefaultConfig()
+	cfg.ReadTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for a negative timeout, got nil")
+	}
+}
+
+func TestServeReportsDrainingBeforeShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.DrainDelay = 200 * time.Millisecond
+	var srv *Server
+	srv = New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if srv.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	if srv.Draining() {
+		t.Error("Expected server not to be draining before shutdown")
+	}
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	// Still accepting requests during the drain delay, but reporting it
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected requests to be served during the drain delay, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected draining to be visible to handlers, got status %d", resp.StatusCode)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import "sync"
+
+// changeNotifier wakes everyone waiting on a poll when its tally changes.
+// Each poll has at most one pending channel; notify closes it, which
+// releases all waiters at once, and the next wait starts a fresh one.
+type changeNotifier struct {
+	mu      sync.Mutex
+	pending map[string]chan struct{}
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{pending: make(map[string]chan struct{})}
+}
+
+func (n *changeNotifier) wait(pollID string) <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch, ok := n.pending[pollID]
+	if !ok {
+		ch = make(chan struct{})
+		n.pending[pollID] = ch
+	}
+	return ch
+}
+
+func (n *changeNotifier) notify(pollID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if ch, ok := n.pending[pollID]; ok {
+		close(ch)
+		delete(n.pending, pollID)
+	}
+}
@@ -1,43 +1,311 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"slices"
+	"time"
 
 	"polling-system/domain"
 	"polling-system/ports"
 )
 
+type Config struct {
+	// SigningKey signs vote receipts and tally commitments. When nil, a key
+	// is generated and receipts only verify on this instance.
+	SigningKey ed25519.PrivateKey
+	// MaxOptions caps the number of options a poll may offer; zero means no limit.
+	MaxOptions int
+	// Events shares tally changes with other instances; nil keeps them
+	// within this one.
+	Events ports.EventBus
+	// HistoryInterval is how often RecordHistory snapshots the tally of
+	// polls that changed; zero records only on creation and close.
+	HistoryInterval time.Duration
+}
+
 type PollService struct {
-	repo ports.PollRepository
+	repo     ports.PollRepository
+	signer   ed25519.PrivateKey
+	cfg      Config
+	changes  *changeNotifier
+	recorder *historyRecorder
+	// origin tells this instance's events apart from those of others
+	origin string
 }
 
-func NewPollService(repo ports.PollRepository) *PollService {
-	return &PollService{repo: repo}
+func NewPollService(repo ports.PollRepository, cfg Config) *PollService {
+	origin := make([]byte, 8)
+	_, _ = rand.Read(origin)
+	signer := cfg.SigningKey
+	if signer == nil {
+		_, signer, _ = ed25519.GenerateKey(rand.Reader)
+	}
+	return &PollService{
+		repo:     repo,
+		signer:   signer,
+		cfg:      cfg,
+		changes:  newChangeNotifier(),
+		recorder: newHistoryRecorder(),
+		origin:   hex.EncodeToString(origin),
+	}
 }
 
-func (s *PollService) CreatePoll(poll domain.Poll) error {
-	return s.repo.CreatePoll(poll)
+// Listen wakes this instance's live update waiters for changes published
+// by other instances on cfg.Events, until the returned function is called.
+func (s *PollService) Listen() (func(), error) {
+	if s.cfg.Events == nil {
+		return func() {}, nil
+	}
+	return s.cfg.Events.Subscribe(func(event domain.TallyChanged) {
+		if event.Origin != s.origin {
+			s.changes.notify(event.PollID)
+		}
+	})
+}
+
+func (s *PollService) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	if poll.ID == "" {
+		return fmt.Errorf("%w: poll id is required", domain.ErrInvalidPoll)
+	}
+	if len(poll.Options) < 2 {
+		return fmt.Errorf("%w: poll needs at least two options", domain.ErrInvalidPoll)
+	}
+	if s.cfg.MaxOptions > 0 && len(poll.Options) > s.cfg.MaxOptions {
+		return fmt.Errorf("%w: poll has %d options, at most %d are allowed", domain.ErrInvalidPoll, len(poll.Options), s.cfg.MaxOptions)
+	}
+	if poll.CreatedAt == nil {
+		now := time.Now().UTC()
+		poll.CreatedAt = &now
+	}
+	if err := s.repo.CreatePoll(ctx, poll); err != nil {
+		return err
+	}
+	if poll.ClosesAt != nil {
+		s.recorder.closesAt(poll.ID, *poll.ClosesAt)
+	}
+	// The poll exists now, so a missing first snapshot only leaves a gap in
+	// its history rather than failing the request
+	_ = s.snapshot(context.WithoutCancel(ctx), poll.ID, *poll.CreatedAt, false)
+	return nil
 }
 
-func (s *PollService) Vote(vote domain.Vote) error {
+func (s *PollService) Vote(ctx context.Context, vote domain.Vote) (domain.Ballot, error) {
 	// Check if the poll exists before voting
-	_, err := s.repo.GetPoll(vote.PollID)
+	poll, err := s.repo.GetPoll(ctx, vote.PollID)
 	if err != nil {
-		return fmt.Errorf("poll not found: %w", err)
+		return domain.Ballot{}, fmt.Errorf("poll not found: %w", err)
+	}
+	if !slices.Contains(poll.Options, vote.Option) {
+		return domain.Ballot{}, fmt.Errorf("%w %q for poll %s", domain.ErrInvalidOption, vote.Option, vote.PollID)
+	}
+	if !poll.Open(time.Now()) {
+		return domain.Ballot{}, fmt.Errorf("%w: %s", domain.ErrPollClosed, vote.PollID)
 	}
-	return s.repo.Vote(vote)
+	ballot, err := s.issueBallot(vote)
+	if err != nil {
+		return domain.Ballot{}, err
+	}
+	if err := s.repo.Vote(ctx, vote, ballot.Receipt.Commitment); err != nil {
+		return domain.Ballot{}, err
+	}
+	s.changes.notify(vote.PollID)
+	s.recorder.markChanged(vote.PollID)
+	s.publish(ctx, vote.PollID)
+	return ballot, nil
 }
 
-func (s *PollService) VoteMultiple(multiVote domain.MultiVote) error {
+func (s *PollService) VoteMultiple(ctx context.Context, multiVote domain.MultiVote) ([]domain.Ballot, error) {
+	ballots := make([]domain.Ballot, 0, len(multiVote.Votes))
 	for _, vote := range multiVote.Votes {
-		err := s.Vote(vote)
+		if err := ctx.Err(); err != nil {
+			return ballots, err
+		}
+		ballot, err := s.Vote(ctx, vote)
 		if err != nil {
-			return fmt.Errorf("error voting on poll %s: %w", vote.PollID, err)
+			return ballots, fmt.Errorf("error voting on poll %s: %w", vote.PollID, err)
 		}
+		ballots = append(ballots, ballot)
 	}
+	return ballots, nil
+}
+
+func (s *PollService) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	return s.repo.GetResults(ctx, pollID)
+}
+
+// publish tells other instances about a change. A lost event only delays
+// their live updates until the next change, so errors are not reported to
+// the voter whose vote is already counted.
+func (s *PollService) publish(ctx context.Context, pollID string) {
+	if s.cfg.Events == nil {
+		return
+	}
+	_ = s.cfg.Events.Publish(context.WithoutCancel(ctx), domain.TallyChanged{PollID: pollID, Origin: s.origin})
+}
+
+func (s *PollService) ResultsChanged(ctx context.Context, pollID string) (<-chan struct{}, error) {
+	if _, err := s.repo.GetPoll(ctx, pollID); err != nil {
+		return nil, err
+	}
+	return s.changes.wait(pollID), nil
+}
+
+func (s *PollService) ClosePoll(ctx context.Context, pollID string) error {
+	history, err := s.history()
+	if err != nil {
+		return err
+	}
+	if err := history.ClosePoll(ctx, pollID); err != nil {
+		return err
+	}
+	s.recorder.forget(pollID)
+	_ = s.snapshot(context.WithoutCancel(ctx), pollID, time.Now(), true)
+	s.changes.notify(pollID)
+	s.publish(ctx, pollID)
+	return nil
+}
+
+func (s *PollService) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	if voterID == "" {
+		return fmt.Errorf("voter id is required")
+	}
+	history, err := s.history()
+	if err != nil {
+		return err
+	}
+	if err := history.RetractVote(ctx, pollID, voterID, nonce); err != nil {
+		return err
+	}
+	s.changes.notify(pollID)
+	s.recorder.markChanged(pollID)
+	s.publish(ctx, pollID)
 	return nil
 }
 
-func (s *PollService) GetResults(pollID string) (domain.PollResult, error) {
-	return s.repo.GetResults(pollID)
+func (s *PollService) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	history, err := s.history()
+	if err != nil {
+		return domain.PollResult{}, err
+	}
+	return history.ResultsAt(ctx, pollID, at)
+}
+
+func (s *PollService) PollEvents(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	history, err := s.history()
+	if err != nil {
+		return nil, err
+	}
+	return history.Events(ctx, pollID)
+}
+
+func (s *PollService) history() (ports.PollHistory, error) {
+	if history, ok := s.repo.(ports.PollHistory); ok {
+		return history, nil
+	}
+	return nil, domain.ErrNoHistory
+}
+
+func (s *PollService) AuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	return s.repo.GetAuditLog(ctx, pollID)
+}
+
+func (s *PollService) TallyCommitment(ctx context.Context, pollID string) (domain.TallyCommitment, error) {
+	leaves, err := s.leaves(ctx, pollID)
+	if err != nil {
+		return domain.TallyCommitment{}, err
+	}
+	return s.tallyCommitment(pollID, leaves), nil
+}
+
+func (s *PollService) VerifyReceipt(ctx context.Context, receipt domain.Receipt) (domain.ReceiptVerification, error) {
+	leaves, err := s.leaves(ctx, receipt.PollID)
+	if err != nil {
+		return domain.ReceiptVerification{}, err
+	}
+	verification := domain.ReceiptVerification{Tally: s.tallyCommitment(receipt.PollID, leaves)}
+
+	signature, err := hex.DecodeString(receipt.Signature)
+	if err != nil || !ed25519.Verify(s.signer.Public().(ed25519.PublicKey), receiptMessage(receipt), signature) {
+		verification.Reason = "invalid receipt signature"
+		return verification, nil
+	}
+	commitment, err := hex.DecodeString(receipt.Commitment)
+	index, found := slices.BinarySearchFunc(leaves, commitment, bytes.Compare)
+	if err != nil || !found {
+		verification.Reason = "commitment not found in tally"
+		return verification, nil
+	}
+
+	proof := inclusionProof(index, leaves)
+	root, _ := hex.DecodeString(verification.Tally.Root)
+	if !verifyInclusion(index, len(leaves), commitment, proof, root) {
+		verification.Reason = "inclusion proof does not match tally root"
+		return verification, nil
+	}
+
+	verification.Valid = true
+	verification.Index = index
+	for _, p := range proof {
+		verification.Proof = append(verification.Proof, hex.EncodeToString(p))
+	}
+	return verification, nil
+}
+
+// issueBallot commits to the vote with a fresh nonce and signs the
+// commitment, so the voter can later prove their ballot is in the tally.
+func (s *PollService) issueBallot(vote domain.Vote) (domain.Ballot, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return domain.Ballot{}, fmt.Errorf("error generating receipt nonce: %w", err)
+	}
+
+	ballot := domain.Ballot{Nonce: hex.EncodeToString(nonce)}
+	ballot.Receipt = domain.Receipt{
+		PollID:     vote.PollID,
+		Commitment: domain.BallotCommitment(vote, ballot.Nonce),
+	}
+	ballot.Receipt.Signature = hex.EncodeToString(ed25519.Sign(s.signer, receiptMessage(ballot.Receipt)))
+	return ballot, nil
+}
+
+func (s *PollService) leaves(ctx context.Context, pollID string) ([][]byte, error) {
+	commitments, err := s.repo.GetCommitments(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([][]byte, len(commitments))
+	for i, c := range commitments {
+		leaves[i], err = hex.DecodeString(c)
+		if err != nil {
+			return nil, fmt.Errorf("corrupt commitment %d for poll %s: %w", i, pollID, err)
+		}
+	}
+	// The tree is built over sorted commitments so a leaf's position says
+	// nothing about when the ballot was cast or which audit entry it matches
+	slices.SortFunc(leaves, bytes.Compare)
+	return leaves, nil
+}
+
+func (s *PollService) tallyCommitment(pollID string, leaves [][]byte) domain.TallyCommitment {
+	tally := domain.TallyCommitment{
+		PollID:    pollID,
+		Size:      len(leaves),
+		Root:      hex.EncodeToString(merkleRoot(leaves)),
+		PublicKey: hex.EncodeToString(s.signer.Public().(ed25519.PublicKey)),
+	}
+	tally.Signature = hex.EncodeToString(ed25519.Sign(s.signer, tallyMessage(tally)))
+	return tally
+}
+
+func receiptMessage(receipt domain.Receipt) []byte {
+	return []byte(fmt.Sprintf("receipt\x00%s\x00%s", receipt.PollID, receipt.Commitment))
+}
+
+func tallyMessage(tally domain.TallyCommitment) []byte {
+	return []byte(fmt.Sprintf("tally\x00%s\x00%d\x00%s", tally.PollID, tally.Size, tally.Root))
 }
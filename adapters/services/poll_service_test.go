@@ -1,15 +1,27 @@
 package services
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"polling-system/adapters/eventbus"
+	"polling-system/adapters/eventstore"
+	"polling-system/adapters/repositories"
 	"polling-system/domain"
 	"polling-system/mocks"
 )
 
 func TestCreatePoll(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
 	poll := domain.Poll{
 		ID:       "1",
@@ -17,13 +29,13 @@ func TestCreatePoll(t *testing.T) {
 		Options:  []string{"Option 1", "Option 2"},
 	}
 
-	err := service.CreatePoll(poll)
+	err := service.CreatePoll(ctx, poll)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
 	// Verify the poll was created
-	createdPoll, err := repo.GetPoll("1")
+	createdPoll, err := repo.GetPoll(ctx, "1")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -33,8 +45,9 @@ func TestCreatePoll(t *testing.T) {
 }
 
 func TestVote(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
 	// Create a poll first
 	poll := domain.Poll{
@@ -42,20 +55,20 @@ func TestVote(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	_ = repo.CreatePoll(poll)
+	_ = repo.CreatePoll(ctx, poll)
 
 	vote := domain.Vote{
 		PollID: "1",
 		Option: "Option 1",
 	}
 
-	err := service.Vote(vote)
+	_, err := service.Vote(ctx, vote)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
 	// Verify the vote was recorded
-	results, err := repo.GetResults("1")
+	results, err := repo.GetResults(ctx, "1")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -65,8 +78,9 @@ func TestVote(t *testing.T) {
 }
 
 func TestGetResults(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
 	// Create a poll and add some votes
 	poll := domain.Poll{
@@ -74,12 +88,12 @@ func TestGetResults(t *testing.T) {
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	_ = repo.CreatePoll(poll)
-	_ = repo.Vote(domain.Vote{PollID: "1", Option: "Option 1"})
-	_ = repo.Vote(domain.Vote{PollID: "1", Option: "Option 2"})
-	_ = repo.Vote(domain.Vote{PollID: "1", Option: "Option 1"})
+	_ = repo.CreatePoll(ctx, poll)
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"}, "")
+	_ = repo.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}, "")
 
-	results, err := service.GetResults("1")
+	results, err := service.GetResults(ctx, "1")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -89,41 +103,464 @@ func TestGetResults(t *testing.T) {
 }
 
 func TestVoteNonExistentPoll(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
 	vote := domain.Vote{
 		PollID: "non_existent",
 		Option: "Option 1",
 	}
 
-	err := service.Vote(vote)
+	_, err := service.Vote(ctx, vote)
 	if err == nil {
 		t.Error("Expected an error when voting on a non-existent poll, got nil")
 	}
 }
 
+func TestVoteInvalidOption(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	_, err := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 3"})
+	if !errors.Is(err, domain.ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+
+	results, _ := repo.GetResults(ctx, "1")
+	if len(results.Results) != 0 {
+		t.Errorf("Expected no votes to be recorded, got %v", results.Results)
+	}
+}
+
+func TestVoteClosedPoll(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+
+	closed := time.Now().Add(-time.Minute)
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}, ClosesAt: &closed})
+
+	_, err := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	if !errors.Is(err, domain.ErrPollClosed) {
+		t.Errorf("Expected ErrPollClosed, got %v", err)
+	}
+
+	results, _ := repo.GetResults(ctx, "1")
+	if len(results.Results) != 0 {
+		t.Errorf("Expected no votes to be recorded, got %v", results.Results)
+	}
+}
+
+func TestResultsChanged(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+
+	if _, err := service.ResultsChanged(ctx, "1"); !errors.Is(err, domain.ErrPollNotFound) {
+		t.Errorf("Expected ErrPollNotFound, got %v", err)
+	}
+
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "2", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	first, _ := service.ResultsChanged(ctx, "1")
+	second, _ := service.ResultsChanged(ctx, "1")
+	other, _ := service.ResultsChanged(ctx, "2")
+
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 3"})
+	select {
+	case <-first:
+		t.Error("Expected a rejected vote not to notify")
+	default:
+	}
+
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	for _, ch := range []<-chan struct{}{first, second} {
+		select {
+		case <-ch:
+		default:
+			t.Error("Expected every waiter on the poll to be notified")
+		}
+	}
+	select {
+	case <-other:
+		t.Error("Expected waiters on other polls not to be notified")
+	default:
+	}
+
+	next, _ := service.ResultsChanged(ctx, "1")
+	select {
+	case <-next:
+		t.Error("Expected a fresh channel after the notification")
+	default:
+	}
+}
+
+func TestPollHistory(t *testing.T) {
+	ctx := context.Background()
+	service := NewPollService(repositories.NewEventSourcedRepository(eventstore.NewMemory()), testConfig(t))
+
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	ballot, _ := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1", VoterID: "alice"})
+	afterVote := time.Now()
+
+	if err := service.RetractVote(ctx, "1", "", ballot.Nonce); err == nil {
+		t.Error("Expected an error retracting without a voter ID")
+	}
+	if err := service.RetractVote(ctx, "1", "alice", "guess"); !errors.Is(err, domain.ErrNotBallotOwner) {
+		t.Errorf("Expected ErrNotBallotOwner retracting without the ballot's nonce, got %v", err)
+	}
+	changed, _ := service.ResultsChanged(ctx, "1")
+	if err := service.RetractVote(ctx, "1", "alice", ballot.Nonce); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Error("Expected a retraction to notify waiters")
+	}
+
+	changed, _ = service.ResultsChanged(ctx, "1")
+	if err := service.ClosePoll(ctx, "1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Error("Expected closing the poll to notify waiters")
+	}
+	if _, err := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}); !errors.Is(err, domain.ErrPollClosed) {
+		t.Errorf("Expected ErrPollClosed, got %v", err)
+	}
+
+	past, err := service.ResultsAt(ctx, "1", afterVote)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if past.Results["Option 1"] != 1 || !past.Poll.Open(afterVote) {
+		t.Errorf("Expected the open poll with one vote, got %+v", past)
+	}
+	if current, _ := service.GetResults(ctx, "1"); current.Results["Option 1"] != 0 {
+		t.Errorf("Expected the retraction to remove the vote, got %v", current.Results)
+	}
+	if events, _ := service.PollEvents(ctx, "1"); len(events) != 6 {
+		t.Errorf("Expected created, snapshot, cast, retracted, closed and snapshot events, got %v", events)
+	}
+}
+
+func TestTallyHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	cfg := testConfig(t)
+	cfg.HistoryInterval = time.Minute
+	service := NewPollService(repo, cfg)
+
+	created := time.Now().Add(-time.Hour)
+	closesAt := time.Now().Add(time.Hour)
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}, CreatedAt: &created, ClosesAt: &closesAt})
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	service.recordDue(time.Now())
+	_, _ = service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	history, err := service.TallyHistory(ctx, "1", 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(history.Points) != 3 {
+		t.Fatalf("Expected creation, interval and current points, got %+v", history.Points)
+	}
+	if first := history.Points[0]; !first.At.Equal(created) || first.Results["Option 1"] != 0 || first.Results["Option 2"] != 0 {
+		t.Errorf("Expected an empty tally at creation, got %+v", first)
+	}
+	if second := history.Points[1]; second.Results["Option 1"] != 1 || second.Results["Option 2"] != 0 {
+		t.Errorf("Expected one vote at the interval, got %+v", second)
+	}
+	if last := history.Points[2]; last.Results["Option 2"] != 1 || last.Version != 2 {
+		t.Errorf("Expected the current tally last, got %+v", last)
+	}
+
+	// Nothing changed since, so only the poll's closing is recorded
+	service.recordDue(closesAt)
+	stored, _ := repo.GetTallyHistory(ctx, "1")
+	if len(stored) != 3 || !stored[2].Final || !stored[2].At.Equal(closesAt) {
+		t.Errorf("Expected a final snapshot at closes_at, got %+v", stored)
+	}
+	if history, _ := service.TallyHistory(ctx, "1", 0, 0); len(history.Points) != 3 {
+		t.Errorf("Expected no current point after the final one, got %+v", history.Points)
+	}
+	if history, _ := service.TallyHistory(ctx, "1", 0, 1); len(history.Points) != 1 || !history.Points[0].Final {
+		t.Errorf("Expected downsampling to keep the final point, got %+v", history.Points)
+	}
+}
+
+func TestPollHistoryUnsupported(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	if err := service.ClosePoll(ctx, "1"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	if _, err := service.ResultsAt(ctx, "1", time.Now()); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestResultsChangedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	events := eventbus.NewInProcess()
+
+	cfg := testConfig(t)
+	cfg.Events = events
+	first := NewPollService(repo, cfg)
+	second := NewPollService(repo, cfg)
+	for _, service := range []*PollService{first, second} {
+		stop, _ := service.Listen()
+		defer stop()
+	}
+
+	_ = first.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	changed, _ := second.ResultsChanged(ctx, "1")
+
+	_, _ = first.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	select {
+	case <-changed:
+	default:
+		t.Error("Expected a vote on one instance to wake waiters on the other")
+	}
+}
+
+func TestVoteMultipleCancelled(t *testing.T) {
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+
+	_ = repo.CreatePoll(context.Background(), domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ballots, err := service.VoteMultiple(ctx, domain.MultiVote{Votes: []domain.Vote{
+		{PollID: "1", Option: "Option 1"},
+		{PollID: "1", Option: "Option 2"},
+	}})
+	if !errors.Is(err, context.Canceled) || len(ballots) != 0 {
+		t.Errorf("Expected context.Canceled and no ballots, got %v, %v", ballots, err)
+	}
+
+	results, _ := repo.GetResults(context.Background(), "1")
+	if len(results.Results) != 0 {
+		t.Errorf("Expected no votes to be recorded, got %v", results.Results)
+	}
+}
+
 func TestGetResultsNonExistentPoll(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
-	_, err := service.GetResults("non_existent")
+	_, err := service.GetResults(ctx, "non_existent")
 	if err == nil {
 		t.Error("Expected an error when getting results for a non-existent poll, got nil")
 	}
 }
 
+func TestVoteReceiptVerifies(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+
+	var ballots []domain.Ballot
+	var receipts []domain.Receipt
+	for i := 0; i < 7; i++ {
+		ballot, err := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		ballots = append(ballots, ballot)
+		receipts = append(receipts, ballot.Receipt)
+	}
+
+	if ballots[2].Receipt.Commitment != domain.BallotCommitment(domain.Vote{PollID: "1", Option: "Option 1"}, ballots[2].Nonce) {
+		t.Error("Expected receipt commitment to open to the cast ballot")
+	}
+	if shared, _ := json.Marshal(ballots[2].Receipt); strings.Contains(string(shared), ballots[2].Nonce) {
+		t.Errorf("Expected the shareable receipt to leave out the nonce, got %s", shared)
+	}
+	if commitments, _ := repo.GetCommitments(ctx, "1"); len(commitments) != len(ballots) {
+		t.Errorf("Expected a commitment recorded with every vote, got %d", len(commitments))
+	}
+
+	tally, err := service.TallyCommitment(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tally.Size != len(receipts) {
+		t.Errorf("Expected tally size %d, got %d", len(receipts), tally.Size)
+	}
+
+	root, _ := hex.DecodeString(tally.Root)
+	for i, receipt := range receipts {
+		verification, err := service.VerifyReceipt(ctx, receipt)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !verification.Valid {
+			t.Errorf("Expected receipt %d to verify, got %q", i, verification.Reason)
+		}
+		if verification.Tally.Root != tally.Root {
+			t.Errorf("Expected root %s, got %s", tally.Root, verification.Tally.Root)
+		}
+
+		// The returned index and proof are enough to check inclusion offline
+		leaf, _ := hex.DecodeString(receipt.Commitment)
+		var proof [][]byte
+		for _, p := range verification.Proof {
+			node, _ := hex.DecodeString(p)
+			proof = append(proof, node)
+		}
+		if !verifyInclusion(verification.Index, tally.Size, leaf, proof, root) {
+			t.Errorf("Expected the proof for receipt %d to check against the tally root", i)
+		}
+	}
+}
+
+func TestVerifyReceiptRejectsTampering(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	service := NewPollService(repo, testConfig(t))
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	first, _ := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	second, _ := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2"})
+
+	forged := first.Receipt
+	forged.Commitment = second.Receipt.Commitment
+	verification, err := service.VerifyReceipt(ctx, forged)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if verification.Valid {
+		t.Error("Expected a receipt with a swapped commitment to be rejected")
+	}
+
+	foreign := first.Receipt
+	foreign.Signature = second.Receipt.Signature
+	verification, _ = service.VerifyReceipt(ctx, foreign)
+	if verification.Valid {
+		t.Error("Expected a receipt with a foreign signature to be rejected")
+	}
+}
+
+func TestVoteWithoutSigningKey(t *testing.T) {
+	ctx := context.Background()
+	service := NewPollService(mocks.NewMockRepository(), Config{})
+
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	ballot, err := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if verification, err := service.VerifyReceipt(ctx, ballot.Receipt); err != nil || !verification.Valid {
+		t.Errorf("Expected receipts signed with a generated key to verify, got %+v, %v", verification, err)
+	}
+}
+
+func TestRetractedReceiptFailsVerification(t *testing.T) {
+	ctx := context.Background()
+	service := NewPollService(repositories.NewEventSourcedRepository(eventstore.NewMemory()), testConfig(t))
+
+	_ = service.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test question?", Options: []string{"Option 1", "Option 2"}})
+	retracted, _ := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1", VoterID: "alice"})
+	kept, _ := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 2", VoterID: "bob"})
+	if err := service.RetractVote(ctx, "1", "alice", retracted.Nonce); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if verification, _ := service.VerifyReceipt(ctx, retracted.Receipt); verification.Valid {
+		t.Error("Expected the receipt of a retracted ballot to fail verification")
+	}
+	if verification, _ := service.VerifyReceipt(ctx, kept.Receipt); !verification.Valid {
+		t.Errorf("Expected the remaining receipt to verify, got %q", verification.Reason)
+	}
+	tally, _ := service.TallyCommitment(ctx, "1")
+	result, _ := service.GetResults(ctx, "1")
+	counted := 0
+	for _, n := range result.Results {
+		counted += n
+	}
+	if tally.Size != counted || counted != 1 {
+		t.Errorf("Expected the tally commitment to cover the %d counted votes, got size %d", counted, tally.Size)
+	}
+}
+
+func TestMerkleInclusionProofs(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		leaves := make([][]byte, size)
+		for i := range leaves {
+			leaves[i] = []byte{byte(i)}
+		}
+		root := merkleRoot(leaves)
+		for i := range leaves {
+			if !verifyInclusion(i, size, leaves[i], inclusionProof(i, leaves), root) {
+				t.Errorf("Expected leaf %d of %d to verify", i, size)
+			}
+			if verifyInclusion(i, size, []byte("other"), inclusionProof(i, leaves), root) {
+				t.Errorf("Expected wrong leaf %d of %d to fail", i, size)
+			}
+		}
+	}
+}
+
+func TestCreatePollLimits(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewMockRepository()
+	cfg := testConfig(t)
+	cfg.MaxOptions = 3
+	service := NewPollService(repo, cfg)
+
+	for _, poll := range []domain.Poll{
+		{ID: "", Question: "Test?", Options: []string{"A", "B"}},
+		{ID: "1", Question: "Test?", Options: []string{"A"}},
+		{ID: "2", Question: "Test?", Options: []string{"A", "B", "C", "D"}},
+	} {
+		if err := service.CreatePoll(ctx, poll); !errors.Is(err, domain.ErrInvalidPoll) {
+			t.Errorf("Expected ErrInvalidPoll creating poll %+v, got %v", poll, err)
+		}
+	}
+
+	if err := service.CreatePoll(ctx, domain.Poll{ID: "3", Question: "Test?", Options: []string{"A", "B", "C"}}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func testConfig(t *testing.T) Config {
+	t.Helper()
+	_, signer, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return Config{SigningKey: signer}
+}
+
 /*
 func TestConcurrentVoting(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
 	poll := domain.Poll{
 		ID:       "1",
 		Question: "Test question?",
 		Options:  []string{"Option 1", "Option 2"},
 	}
-	err := service.CreatePoll(poll)
+	err := service.CreatePoll(ctx, poll)
 	if err != nil {
 		t.Fatalf("Failed to create poll: %v", err)
 	}
@@ -142,7 +579,7 @@ func TestConcurrentVoting(t *testing.T) {
 				PollID: "1",
 				Option: "Option 1",
 			}
-			if err := service.Vote(vote); err != nil {
+			if err := service.Vote(ctx, vote); err != nil {
 				errorChan <- err
 			}
 		}()
@@ -158,7 +595,7 @@ func TestConcurrentVoting(t *testing.T) {
 	}
 
 	// Verify the final vote count
-	results, err := service.GetResults("1")
+	results, err := service.GetResults(ctx, "1")
 	if err != nil {
 		t.Fatalf("Failed to get results: %v", err)
 	}
@@ -169,8 +606,9 @@ func TestConcurrentVoting(t *testing.T) {
 }
 
 func TestCreateExistingPoll(t *testing.T) {
+	ctx := context.Background()
 	repo := mocks.NewMockRepository()
-	service := NewPollService(repo)
+	service := NewPollService(repo, testConfig(t))
 
 	poll := domain.Poll{
 		ID:       "1",
@@ -178,13 +616,13 @@ func TestCreateExistingPoll(t *testing.T) {
 		Options:  []string{"Option 1", "Option 2"},
 	}
 
-	err := service.CreatePoll(poll)
+	err := service.CreatePoll(ctx, poll)
 	if err != nil {
 		t.Errorf("Unexpected error creating poll: %v", err)
 	}
 
 	// Attempt to create the same poll again
-	err = service.CreatePoll(poll)
+	err = service.CreatePoll(ctx, poll)
 	if err == nil {
 		t.Error("Expected an error when creating a poll with an existing ID, got nil")
 	}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"polling-system/domain"
+)
+
+// historyRecorder tracks the polls whose tally needs a new snapshot: those
+// that changed since the last one, and those due to close at a set time.
+type historyRecorder struct {
+	mu      sync.Mutex
+	changed map[string]struct{}
+	closing map[string]time.Time
+}
+
+func newHistoryRecorder() *historyRecorder {
+	return &historyRecorder{changed: make(map[string]struct{}), closing: make(map[string]time.Time)}
+}
+
+func (h *historyRecorder) markChanged(pollID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.changed[pollID] = struct{}{}
+}
+
+func (h *historyRecorder) closesAt(pollID string, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closing[pollID] = at
+}
+
+func (h *historyRecorder) forget(pollID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.changed, pollID)
+	delete(h.closing, pollID)
+}
+
+// due takes the polls that changed and those that closed by now.
+func (h *historyRecorder) due(now time.Time) (changed []string, closed map[string]time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	closed = make(map[string]time.Time)
+	for pollID, at := range h.closing {
+		if !now.Before(at) {
+			closed[pollID] = at
+			delete(h.closing, pollID)
+			delete(h.changed, pollID)
+		}
+	}
+	for pollID := range h.changed {
+		changed = append(changed, pollID)
+	}
+	clear(h.changed)
+	return changed, closed
+}
+
+// RecordHistory snapshots the tally of every poll that changed on this
+// instance once per cfg.HistoryInterval, and the final tally of polls it
+// created once their closes_at has passed, until the returned function is
+// called. Polls are also snapshotted when created and when closed early,
+// whether or not this runs.
+func (s *PollService) RecordHistory() (stop func()) {
+	if s.cfg.HistoryInterval <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(s.cfg.HistoryInterval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				s.recordDue(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// recordDue snapshots the polls that are due. A failed snapshot only leaves
+// a gap in the poll's history, so errors are dropped.
+func (s *PollService) recordDue(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.HistoryInterval)
+	defer cancel()
+
+	changed, closed := s.recorder.due(now)
+	for pollID, closesAt := range closed {
+		_ = s.snapshot(ctx, pollID, closesAt, true)
+	}
+	for _, pollID := range changed {
+		_ = s.snapshot(ctx, pollID, now, false)
+	}
+}
+
+// snapshot records the poll's current tally as it stood at the given time.
+func (s *PollService) snapshot(ctx context.Context, pollID string, at time.Time, final bool) error {
+	result, err := s.repo.GetResults(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	snap := domain.NewTallySnapshot(result, at)
+	snap.Final = final
+	return s.repo.AddTallySnapshot(ctx, pollID, snap)
+}
+
+func (s *PollService) TallyHistory(ctx context.Context, pollID string, step time.Duration, maxPoints int) (domain.TallyHistory, error) {
+	points, err := s.repo.GetTallyHistory(ctx, pollID)
+	if err != nil {
+		return domain.TallyHistory{}, err
+	}
+	result, err := s.repo.GetResults(ctx, pollID)
+	if err != nil {
+		return domain.TallyHistory{}, err
+	}
+	// End with the current tally unless it is already the last point
+	if n := len(points); n == 0 || (!points[n-1].Final && result.Version > points[n-1].Version) {
+		points = append(points, domain.NewTallySnapshot(result, result.CapturedAt))
+	}
+	return domain.TallyHistory{
+		PollID:  pollID,
+		Options: result.Poll.Options,
+		Points:  domain.DownsampleHistory(points, step, maxPoints),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"polling-system/internal/httpx"
+)
+
+const instrumentationName = "polling-system/adapters/tracing"
+
+// InstrumentRoute starts a server span named after route for every request,
+// continuing any trace the caller propagated in its headers.
+func InstrumentRoute(route string, next http.HandlerFunc) http.HandlerFunc {
+	tracer := otel.Tracer(instrumentationName)
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if id := r.PathValue("id"); id != "" {
+			span.SetAttributes(attribute.String("poll.id", id))
+		}
+
+		rec := httpx.NewRecorder(w)
+		next(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.Status))
+		if rec.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.Status))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+// PollRepository wraps every call to next in a client span.
+type PollRepository struct {
+	next   ports.PollRepository
+	tracer trace.Tracer
+}
+
+func NewPollRepository(next ports.PollRepository) *PollRepository {
+	return &PollRepository{next: next, tracer: otel.Tracer(instrumentationName)}
+}
+
+func (r *PollRepository) start(ctx context.Context, operation, pollID string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "PollRepository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("poll.id", pollID)),
+	)
+}
+
+func (r *PollRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	ctx, span := r.start(ctx, "CreatePoll", poll.ID)
+	defer span.End()
+	return recordError(span, r.next.CreatePoll(ctx, poll))
+}
+
+func (r *PollRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	ctx, span := r.start(ctx, "GetPoll", id)
+	defer span.End()
+	poll, err := r.next.GetPoll(ctx, id)
+	return poll, recordError(span, err)
+}
+
+func (r *PollRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	ctx, span := r.start(ctx, "Vote", vote.PollID)
+	defer span.End()
+	return recordError(span, r.next.Vote(ctx, vote, commitment))
+}
+
+func (r *PollRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	ctx, span := r.start(ctx, "GetResults", pollID)
+	defer span.End()
+	result, err := r.next.GetResults(ctx, pollID)
+	return result, recordError(span, err)
+}
+
+func (r *PollRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	ctx, span := r.start(ctx, "GetCommitments", pollID)
+	defer span.End()
+	commitments, err := r.next.GetCommitments(ctx, pollID)
+	return commitments, recordError(span, err)
+}
+
+func (r *PollRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	ctx, span := r.start(ctx, "GetAuditLog", pollID)
+	defer span.End()
+	entries, err := r.next.GetAuditLog(ctx, pollID)
+	return entries, recordError(span, err)
+}
+
+func (r *PollRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	ctx, span := r.start(ctx, "AddTallySnapshot", pollID)
+	defer span.End()
+	return recordError(span, r.next.AddTallySnapshot(ctx, pollID, snap))
+}
+
+func (r *PollRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	ctx, span := r.start(ctx, "GetTallyHistory", pollID)
+	defer span.End()
+	history, err := r.next.GetTallyHistory(ctx, pollID)
+	return history, recordError(span, err)
+}
+
+// Flush passes through to next so wrapping a persistent repository doesn't
+// hide it from shutdown.
+func (r *PollRepository) Flush() error {
+	if flusher, ok := r.next.(ports.Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Ready passes through to next; repositories without a readiness check are
+// assumed ready.
+func (r *PollRepository) Ready(ctx context.Context) error {
+	if checker, ok := r.next.(ports.ReadinessChecker); ok {
+		return checker.Ready(ctx)
+	}
+	return nil
+}
+
+// history returns next's history support; wrapping a repository must not
+// hide it from the service.
+func (r *PollRepository) history() (ports.PollHistory, error) {
+	if history, ok := r.next.(ports.PollHistory); ok {
+		return history, nil
+	}
+	return nil, domain.ErrNoHistory
+}
+
+func (r *PollRepository) ClosePoll(ctx context.Context, pollID string) error {
+	ctx, span := r.start(ctx, "ClosePoll", pollID)
+	defer span.End()
+	history, err := r.history()
+	if err != nil {
+		return recordError(span, err)
+	}
+	return recordError(span, history.ClosePoll(ctx, pollID))
+}
+
+func (r *PollRepository) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	ctx, span := r.start(ctx, "RetractVote", pollID)
+	defer span.End()
+	history, err := r.history()
+	if err != nil {
+		return recordError(span, err)
+	}
+	return recordError(span, history.RetractVote(ctx, pollID, voterID, nonce))
+}
+
+func (r *PollRepository) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	ctx, span := r.start(ctx, "ResultsAt", pollID)
+	defer span.End()
+	history, err := r.history()
+	if err != nil {
+		return domain.PollResult{}, recordError(span, err)
+	}
+	result, err := history.ResultsAt(ctx, pollID, at)
+	return result, recordError(span, err)
+}
+
+func (r *PollRepository) Events(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	ctx, span := r.start(ctx, "Events", pollID)
+	defer span.End()
+	history, err := r.history()
+	if err != nil {
+		return nil, recordError(span, err)
+	}
+	events, err := history.Events(ctx, pollID)
+	return events, recordError(span, err)
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"polling-system/domain"
+	"polling-system/ports"
+)
+
+// PollService wraps every call to next in a span. Vote options are left out
+// of span attributes so traces don't reveal ballots.
+type PollService struct {
+	next   ports.PollService
+	tracer trace.Tracer
+}
+
+func NewPollService(next ports.PollService) *PollService {
+	return &PollService{next: next, tracer: otel.Tracer(instrumentationName)}
+}
+
+func (s *PollService) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	ctx, span := s.tracer.Start(ctx, "PollService.CreatePoll", trace.WithAttributes(attribute.String("poll.id", poll.ID)))
+	defer span.End()
+	return recordError(span, s.next.CreatePoll(ctx, poll))
+}
+
+func (s *PollService) Vote(ctx context.Context, vote domain.Vote) (domain.Ballot, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.Vote", trace.WithAttributes(attribute.String("poll.id", vote.PollID)))
+	defer span.End()
+	ballot, err := s.next.Vote(ctx, vote)
+	return ballot, recordError(span, err)
+}
+
+func (s *PollService) VoteMultiple(ctx context.Context, votes domain.MultiVote) ([]domain.Ballot, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.VoteMultiple", trace.WithAttributes(attribute.Int("votes.count", len(votes.Votes))))
+	defer span.End()
+	ballots, err := s.next.VoteMultiple(ctx, votes)
+	span.SetAttributes(attribute.Int("votes.accepted", len(ballots)))
+	return ballots, recordError(span, err)
+}
+
+func (s *PollService) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.GetResults", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	result, err := s.next.GetResults(ctx, pollID)
+	return result, recordError(span, err)
+}
+
+func (s *PollService) TallyCommitment(ctx context.Context, pollID string) (domain.TallyCommitment, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.TallyCommitment", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	tally, err := s.next.TallyCommitment(ctx, pollID)
+	return tally, recordError(span, err)
+}
+
+func (s *PollService) VerifyReceipt(ctx context.Context, receipt domain.Receipt) (domain.ReceiptVerification, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.VerifyReceipt", trace.WithAttributes(attribute.String("poll.id", receipt.PollID)))
+	defer span.End()
+	verification, err := s.next.VerifyReceipt(ctx, receipt)
+	span.SetAttributes(attribute.Bool("receipt.valid", verification.Valid))
+	return verification, recordError(span, err)
+}
+
+func (s *PollService) AuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.AuditLog", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	entries, err := s.next.AuditLog(ctx, pollID)
+	return entries, recordError(span, err)
+}
+
+// ResultsChanged only traces the subscription; the wait on the returned
+// channel belongs to the caller's span.
+func (s *PollService) ResultsChanged(ctx context.Context, pollID string) (<-chan struct{}, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.ResultsChanged", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	changed, err := s.next.ResultsChanged(ctx, pollID)
+	return changed, recordError(span, err)
+}
+
+func (s *PollService) ClosePoll(ctx context.Context, pollID string) error {
+	ctx, span := s.tracer.Start(ctx, "PollService.ClosePoll", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	return recordError(span, s.next.ClosePoll(ctx, pollID))
+}
+
+func (s *PollService) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	ctx, span := s.tracer.Start(ctx, "PollService.RetractVote", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	return recordError(span, s.next.RetractVote(ctx, pollID, voterID, nonce))
+}
+
+func (s *PollService) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.ResultsAt", trace.WithAttributes(
+		attribute.String("poll.id", pollID),
+		attribute.String("results.at", at.UTC().Format(time.RFC3339Nano)),
+	))
+	defer span.End()
+	result, err := s.next.ResultsAt(ctx, pollID, at)
+	return result, recordError(span, err)
+}
+
+func (s *PollService) PollEvents(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.PollEvents", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	defer span.End()
+	events, err := s.next.PollEvents(ctx, pollID)
+	span.SetAttributes(attribute.Int("events.count", len(events)))
+	return events, recordError(span, err)
+}
+
+func (s *PollService) TallyHistory(ctx context.Context, pollID string, step time.Duration, maxPoints int) (domain.TallyHistory, error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.TallyHistory", trace.WithAttributes(
+		attribute.String("poll.id", pollID),
+		attribute.String("history.step", step.String()),
+		attribute.Int("history.max_points", maxPoints),
+	))
+	defer span.End()
+	history, err := s.next.TallyHistory(ctx, pollID, step, maxPoints)
+	span.SetAttributes(attribute.Int("history.points", len(history.Points)))
+	return history, recordError(span, err)
+}
+
+func recordError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
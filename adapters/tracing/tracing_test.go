@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"polling-system/adapters/repositories"
+	"polling-system/adapters/services"
+	"polling-system/domain"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spansByName(spans []sdktrace.ReadOnlySpan) map[string]sdktrace.ReadOnlySpan {
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans {
+		byName[span.Name()] = span
+	}
+	return byName
+}
+
+func TestVoteSpansAcrossLayers(t *testing.T) {
+	ctx := context.Background()
+	recorder := recordSpans(t)
+
+	repo := NewPollRepository(repositories.NewMemoryRepository())
+	service := NewPollService(services.NewPollService(repo, services.Config{SigningKey: testKey()}))
+
+	_ = repo.CreatePoll(ctx, domain.Poll{ID: "1", Question: "Test?", Options: []string{"Option 1", "Option 2"}})
+	if _, err := service.Vote(ctx, domain.Vote{PollID: "1", Option: "Option 1"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spans := spansByName(recorder.Ended())
+	root, ok := spans["PollService.Vote"]
+	if !ok {
+		t.Fatalf("Expected a PollService.Vote span, got %v", spans)
+	}
+	for _, name := range []string{"PollRepository.GetPoll", "PollRepository.Vote"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("Expected a %s span", name)
+			continue
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of PollService.Vote", name)
+		}
+	}
+
+	lockTraced := false
+	for _, span := range recorder.Ended() {
+		if span.Name() == "MemoryRepository.voteMutex.Lock" && span.Parent().SpanID() == spans["PollRepository.Vote"].SpanContext().SpanID() {
+			lockTraced = true
+		}
+	}
+	if !lockTraced {
+		t.Error("Expected the vote lock wait to be traced under PollRepository.Vote")
+	}
+
+	for _, attr := range root.Attributes() {
+		if attr.Value.AsString() == "Option 1" {
+			t.Errorf("Expected the ballot option to stay out of span attributes, found %s", attr.Key)
+		}
+	}
+}
+
+func TestRejectedVoteRecordsError(t *testing.T) {
+	recorder := recordSpans(t)
+
+	service := NewPollService(services.NewPollService(repositories.NewMemoryRepository(), services.Config{SigningKey: testKey()}))
+	if _, err := service.Vote(context.Background(), domain.Vote{PollID: "missing", Option: "Option 1"}); err == nil {
+		t.Fatal("Expected an error voting on a missing poll")
+	}
+
+	span := spansByName(recorder.Ended())["PollService.Vote"]
+	if span == nil || span.Status().Description == "" || len(span.Events()) == 0 {
+		t.Errorf("Expected the span to carry the error, got %+v", span)
+	}
+}
+
+func TestInstrumentRouteContinuesTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	_, _ = Setup(context.Background(), Config{Exporter: ExporterNone})
+
+	var inner trace.SpanContext
+	handler := InstrumentRoute("/results/{id}", func(w http.ResponseWriter, r *http.Request) {
+		inner = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest("GET", "/results/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler(httptest.NewRecorder(), req)
+
+	if inner.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the caller's trace to continue, got trace %s", inner.TraceID())
+	}
+
+	span := spansByName(recorder.Ended())["GET /results/{id}"]
+	if span == nil || span.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("Expected a server span for the route, got %v", recorder.Ended())
+	}
+	var status int64
+	for _, attr := range span.Attributes() {
+		if attr.Key == "http.response.status_code" {
+			status = attr.Value.AsInt64()
+		}
+	}
+	if status != http.StatusTeapot {
+		t.Errorf("Expected status %d on the span, got %d", http.StatusTeapot, status)
+	}
+}
+
+func testKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, cfg := range []Config{
+		{Exporter: "zipkin", SampleRatio: 1},
+		{Exporter: ExporterFile, SampleRatio: 1},
+		{Exporter: ExporterStdout, SampleRatio: 1.5},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v, got nil", cfg)
+		}
+	}
+}
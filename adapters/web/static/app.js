@@ -0,0 +1,110 @@
+// Shared helpers of the polling UI. Plain browser JavaScript, no build step.
+"use strict";
+
+// Tableau 10, as in the server-rendered charts.
+const PALETTE = ["#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+  "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"];
+
+function $(selector) {
+  return document.querySelector(selector);
+}
+
+// el builds an element with text content, so user input is never parsed as HTML.
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs || {})) {
+    if (name === "text") {
+      node.textContent = value;
+    } else {
+      node.setAttribute(name, value);
+    }
+  }
+  node.append(...children);
+  return node;
+}
+
+function pollIDFromQuery() {
+  return new URLSearchParams(location.search).get("id") || "";
+}
+
+function pollPath(id) {
+  return encodeURIComponent(id);
+}
+
+// api calls the JSON API and throws an Error with the server's message on
+// failure.
+async function api(method, path, body, token) {
+  const headers = {};
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  if (token) {
+    headers["Authorization"] = "Bearer " + token;
+  }
+  const response = await fetch(path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const text = await response.text();
+  if (!response.ok) {
+    throw new Error(text.trim() || response.statusText);
+  }
+  return text ? JSON.parse(text) : null;
+}
+
+function showError(target, err) {
+  target.replaceChildren(el("p", { class: "error", text: err.message || String(err) }));
+}
+
+function isOpen(poll) {
+  return !poll.closes_at || new Date(poll.closes_at) > new Date();
+}
+
+function formatPercent(p) {
+  return (Number.isInteger(p) ? p : p.toFixed(1)) + "%";
+}
+
+function votesLabel(total) {
+  return total === 1 ? "1 vote" : total + " votes";
+}
+
+// renderResults draws one bar per option, scaled to the leading one, with
+// its count and share of all votes.
+function renderResults(target, result) {
+  const options = result.Poll.Options || [];
+  const counts = options.map((option) => (result.Results || {})[option] || 0);
+  const total = counts.reduce((a, b) => a + b, 0);
+  const most = Math.max(0, ...counts);
+
+  const bars = options.map((option, i) => {
+    const percent = total ? (100 * counts[i]) / total : 0;
+    const fill = el("div", { class: "bar-fill" });
+    fill.style.width = (most ? (100 * counts[i]) / most : 0) + "%";
+    fill.style.background = PALETTE[i % PALETTE.length];
+    return el("div", { class: "bar" },
+      el("div", { class: "bar-label" },
+        el("span", { text: option }),
+        el("span", { text: counts[i] + " (" + formatPercent(percent) + ")" })),
+      el("div", { class: "bar-track" }, fill));
+  });
+  target.replaceChildren(...bars, el("p", { class: "muted", text: votesLabel(total) }));
+}
+
+// watchResults follows /poll_updates/{id}, calling onResults with every
+// tally and onEnd once the poll closes or disappears. EventSource
+// reconnects by itself after network errors and server restarts.
+function watchResults(id, onResults, onEnd) {
+  const source = new EventSource("/poll_updates/" + pollPath(id));
+  source.addEventListener("results", (e) => onResults(JSON.parse(e.data)));
+  source.addEventListener("closed", (e) => {
+    source.close();
+    onResults(JSON.parse(e.data));
+    onEnd("closed");
+  });
+  source.addEventListener("deleted", () => {
+    source.close();
+    onEnd("deleted");
+  });
+  return source;
+}
@@ -0,0 +1,28 @@
+// Package web serves the browser UI for creating polls, voting and watching
+// live results. It is plain HTML, CSS and JavaScript embedded in the binary,
+// so there is no build step and nothing to deploy besides the server.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the UI's files from the root; mount it under a prefix with
+// http.StripPrefix.
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.FileServer(http.FS(files))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Embedded files have no modification time to revalidate against
+		w.Header().Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	})
+}
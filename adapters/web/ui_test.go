@@ -0,0 +1,48 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	handler := http.StripPrefix("/ui", Handler())
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/ui/", "text/html", `id="create"`},
+		{"/ui/vote.html", "text/html", `"/vote"`},
+		{"/ui/results.html", "text/html", "watchResults"},
+		{"/ui/present.html", "text/html", "/qr.svg"},
+		{"/ui/app.js", "text/javascript", "/poll_updates/"},
+		{"/ui/style.css", "text/css", "--accent"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.path, rr.Code)
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+			t.Errorf("%s: expected content type %s, got %q", tt.path, tt.contentType, ct)
+		}
+		if !strings.Contains(rr.Body.String(), tt.contains) {
+			t.Errorf("%s: expected %q in the body", tt.path, tt.contains)
+		}
+		if rr.Header().Get("Cache-Control") != "no-cache" {
+			t.Errorf("%s: expected revalidation, got %q", tt.path, rr.Header().Get("Cache-Control"))
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/ui/missing.html", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing file, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,172 @@
+// Command auditverify checks a poll's audit log: it verifies the hash chain,
+// recomputes the tallies from the logged votes and compares them with the
+// results the server reports.
+//
+//	go run ./cmd/auditverify -server http://localhost:8080 -poll 1
+//	go run ./cmd/auditverify -server http://localhost:8080 -poll 1 -log poll-1-audit.ndjson
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+
+	"polling-system/domain"
+)
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "base URL of the polling server")
+	pollID := flag.String("poll", "", "ID of the poll to verify")
+	logPath := flag.String("log", "", "read the audit log from this file instead of downloading it")
+	flag.Parse()
+
+	if *pollID == "" {
+		fmt.Fprintln(os.Stderr, "auditverify: -poll is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*server, *pollID, *logPath); err != nil {
+		fmt.Fprintln(os.Stderr, "auditverify:", err)
+		os.Exit(1)
+	}
+}
+
+// maxAttempts bounds how often the log is downloaded again while votes keep
+// arriving during verification.
+const maxAttempts = 3
+
+func run(server, pollID, logPath string) error {
+	var entries []domain.AuditEntry
+	var result domain.PollResult
+	var err error
+	if logPath != "" {
+		if entries, err = readLogFile(logPath); err == nil {
+			err = getJSON(resultsURL(server, pollID), &result)
+		}
+	} else {
+		entries, result, err = fetchConsistent(server, pollID)
+	}
+	if err != nil {
+		return err
+	}
+
+	tallies, err := domain.VerifyAuditLog(pollID, entries)
+	if err != nil {
+		return fmt.Errorf("audit chain is broken: %w", err)
+	}
+	fmt.Printf("hash chain intact: %d entries\n", len(entries))
+
+	if mismatches := compareTallies(tallies, result.Results); len(mismatches) > 0 {
+		for _, m := range mismatches {
+			fmt.Println(m)
+		}
+		return fmt.Errorf("recomputed tallies do not match reported results")
+	}
+	fmt.Println("recomputed tallies match reported results")
+	return nil
+}
+
+func compareTallies(fromLog, reported map[string]int) []string {
+	options := make(map[string]bool)
+	for option := range fromLog {
+		options[option] = true
+	}
+	for option := range reported {
+		options[option] = true
+	}
+
+	var mismatches []string
+	for option := range options {
+		if fromLog[option] != reported[option] {
+			mismatches = append(mismatches, fmt.Sprintf("option %q: log has %d votes, results report %d",
+				option, fromLog[option], reported[option]))
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// fetchConsistent downloads the audit log between two reads of the results
+// and retries while their versions differ, so a vote cast in between is not
+// mistaken for tampering.
+func fetchConsistent(server, pollID string) ([]domain.AuditEntry, domain.PollResult, error) {
+	for attempt := 1; ; attempt++ {
+		var before, after domain.PollResult
+		if err := getJSON(resultsURL(server, pollID), &before); err != nil {
+			return nil, domain.PollResult{}, err
+		}
+		entries, err := fetchLog(server, pollID)
+		if err != nil {
+			return nil, domain.PollResult{}, err
+		}
+		if err := getJSON(resultsURL(server, pollID), &after); err != nil {
+			return nil, domain.PollResult{}, err
+		}
+		if before.Version == after.Version {
+			return entries, after, nil
+		}
+		if attempt == maxAttempts {
+			return nil, domain.PollResult{}, fmt.Errorf("poll changed during verification %d times, try again later", maxAttempts)
+		}
+	}
+}
+
+func resultsURL(server, pollID string) string {
+	return server + "/results/" + url.PathEscape(pollID)
+}
+
+func fetchLog(server, pollID string) ([]domain.AuditEntry, error) {
+	resp, err := http.Get(server + "/polls/" + url.PathEscape(pollID) + "/audit")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading audit log: %s", resp.Status)
+	}
+	return decodeLog(resp.Body)
+}
+
+func readLogFile(path string) ([]domain.AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeLog(f)
+}
+
+func decodeLog(r io.Reader) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var entry domain.AuditEntry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding audit entry %d: %w", len(entries), err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func getJSON(u string, v any) error {
+	resp, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"polling-system/domain"
+)
+
+// fakeServer serves a poll's audit log and results. onAudit runs after
+// each audit log download, to cast votes in between the two requests.
+type fakeServer struct {
+	mu      sync.Mutex
+	entries []domain.AuditEntry
+	results map[string]int
+	onAudit func(*fakeServer)
+}
+
+func (s *fakeServer) vote(option string) {
+	var prev *domain.AuditEntry
+	if len(s.entries) > 0 {
+		prev = &s.entries[len(s.entries)-1]
+	}
+	s.entries = append(s.entries, domain.NewAuditEntry(prev, domain.Vote{PollID: "1", Option: option}, time.Now()))
+	s.results[option]++
+}
+
+func (s *fakeServer) start(t *testing.T) string {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /polls/1/audit", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		enc := json.NewEncoder(w)
+		for _, entry := range s.entries {
+			_ = enc.Encode(entry)
+		}
+		if s.onAudit != nil {
+			s.onAudit(s)
+		}
+	})
+	mux.HandleFunc("GET /results/1", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(domain.PollResult{Results: s.results, Version: uint64(len(s.entries))})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func newFakeServer() *fakeServer {
+	s := &fakeServer{results: make(map[string]int)}
+	s.vote("Yes")
+	s.vote("No")
+	s.vote("Yes")
+	return s
+}
+
+func TestRunMatches(t *testing.T) {
+	s := newFakeServer()
+	if err := run(s.start(t), "1", ""); err != nil {
+		t.Errorf("Expected the log to match the results, got %v", err)
+	}
+}
+
+func TestRunDetectsTampering(t *testing.T) {
+	s := newFakeServer()
+	s.results["Yes"]++
+	if err := run(s.start(t), "1", ""); err == nil || !strings.Contains(err.Error(), "do not match") {
+		t.Errorf("Expected a tally mismatch, got %v", err)
+	}
+
+	s = newFakeServer()
+	s.entries[1].Option = "Yes"
+	if err := run(s.start(t), "1", ""); err == nil || !strings.Contains(err.Error(), "chain is broken") {
+		t.Errorf("Expected a broken chain, got %v", err)
+	}
+}
+
+func TestRunRetriesWhileVotesArrive(t *testing.T) {
+	s := newFakeServer()
+	votes := 0
+	s.onAudit = func(s *fakeServer) {
+		if votes < 2 {
+			s.vote("No")
+			votes++
+		}
+	}
+	if err := run(s.start(t), "1", ""); err != nil {
+		t.Errorf("Expected votes cast during verification not to count as tampering, got %v", err)
+	}
+
+	s = newFakeServer()
+	s.onAudit = func(s *fakeServer) { s.vote("No") }
+	if err := run(s.start(t), "1", ""); err == nil || !strings.Contains(err.Error(), "changed during verification") {
+		t.Errorf("Expected verification to give up on a busy poll, got %v", err)
+	}
+}
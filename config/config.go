@@ -0,0 +1,518 @@
+// Package config assembles the server configuration from, in increasing order
+// of precedence: built-in defaults, a YAML or JSON config file, POLLING_*
+// environment variables and command-line flags.
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"polling-system/adapters/eventbus"
+	"polling-system/adapters/handlers"
+	"polling-system/adapters/logging"
+	"polling-system/adapters/repositories"
+	"polling-system/adapters/server"
+	"polling-system/adapters/tracing"
+)
+
+const envPrefix = "POLLING_"
+
+const (
+	RepositoryMemory   = "memory"
+	RepositorySnapshot = "snapshot"
+	RepositorySharded  = "sharded"
+	RepositoryRaft     = "raft"
+	RepositoryEvents   = "events"
+)
+
+const (
+	EventBusInProcess = "inprocess"
+	EventBusNATS      = "nats"
+)
+
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Receipts ReceiptsConfig `yaml:"receipts"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Limits   LimitsConfig   `yaml:"limits"`
+	Live     LiveConfig     `yaml:"live"`
+	History  HistoryConfig  `yaml:"history"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Events   EventsConfig   `yaml:"events"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes  int           `yaml:"max_header_bytes"`
+	TLSCertFile     string        `yaml:"tls_cert"`
+	TLSKeyFile      string        `yaml:"tls_key"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	DrainDelay      time.Duration `yaml:"drain_delay"`
+	// RequestTimeout bounds each non-streaming request, including the
+	// repository work it triggers.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// PublicURL is where clients reach the server, e.g. behind a proxy. Link
+	// previews need absolute URLs; when empty they are taken from requests.
+	PublicURL string `yaml:"public_url"`
+}
+
+type StorageConfig struct {
+	// Repository is "memory", "snapshot", "sharded", "raft" or "events".
+	Repository   string `yaml:"repository"`
+	SnapshotPath string `yaml:"snapshot_path"`
+	// EventLog is the file the events repository appends to; empty keeps
+	// the events in memory.
+	EventLog string `yaml:"event_log"`
+	// Stripes is the number of lock stripes of the sharded repository.
+	Stripes int        `yaml:"stripes"`
+	Raft    RaftConfig `yaml:"raft"`
+}
+
+type RaftConfig struct {
+	// NodeID picks this node out of Peers.
+	NodeID string    `yaml:"node_id"`
+	Peers  raftPeers `yaml:"peers"`
+	// Secret authenticates the commands followers forward to the leader;
+	// every node needs the same one.
+	Secret string `yaml:"secret"`
+}
+
+// raftPeers reads -raft-peers as a comma-separated list of
+// id@raft_addr@forward_addr entries.
+type raftPeers []repositories.RaftPeer
+
+func (p *raftPeers) String() string {
+	if p == nil {
+		return ""
+	}
+	entries := make([]string, len(*p))
+	for i, peer := range *p {
+		entries[i] = peer.ID + "@" + peer.RaftAddr + "@" + peer.ForwardAddr
+	}
+	return strings.Join(entries, ",")
+}
+
+func (p *raftPeers) Set(value string) error {
+	var peers raftPeers
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "@")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return fmt.Errorf("raft peer %q must look like id@raft_addr@forward_addr", entry)
+		}
+		peers = append(peers, repositories.RaftPeer{ID: parts[0], RaftAddr: parts[1], ForwardAddr: parts[2]})
+	}
+	*p = peers
+	return nil
+}
+
+type ReceiptsConfig struct {
+	// SigningKeyFile holds a hex-encoded Ed25519 seed. When empty, a key is
+	// generated at startup and receipts only verify until the next restart.
+	SigningKeyFile string `yaml:"signing_key_file"`
+}
+
+type AuthConfig struct {
+	// AdminToken, when set, is required as a bearer token to create polls.
+	AdminToken string `yaml:"admin_token"`
+}
+
+type LimitsConfig struct {
+	MaxBodyBytes       int64 `yaml:"max_body_bytes"`
+	MaxOptions         int   `yaml:"max_options"`
+	MaxVotesPerRequest int   `yaml:"max_votes_per_request"`
+	MaxPollsPerStream  int   `yaml:"max_polls_per_stream"`
+}
+
+type LiveConfig struct {
+	UpdateInterval    time.Duration `yaml:"update_interval"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	RetryDelay        time.Duration `yaml:"retry_delay"`
+	// ResyncInterval is how often delta streams send the full tally.
+	ResyncInterval time.Duration `yaml:"resync_interval"`
+	// MaxWait caps the wait parameter of long-polling results requests.
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+type HistoryConfig struct {
+	// Interval is how often the tally of polls that changed is recorded for
+	// /polls/{id}/history; zero records it only on creation and close.
+	Interval time.Duration `yaml:"interval"`
+}
+
+type EventsConfig struct {
+	// Bus is "inprocess" for a single instance or "nats" to share live
+	// updates between replicas.
+	Bus     string `yaml:"bus"`
+	NATSURL string `yaml:"nats_url"`
+	Subject string `yaml:"subject"`
+}
+
+type TracingConfig struct {
+	// Exporter is "none", "stdout", "file" or "otlp".
+	Exporter     string  `yaml:"exporter"`
+	File         string  `yaml:"file"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
+}
+
+type LogConfig struct {
+	// Format is "text" or "json".
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+}
+
+func Default() Config {
+	srv := server.DefaultConfig()
+	h := handlers.DefaultConfig()
+	return Config{
+		Server: ServerConfig{
+			Addr:            srv.Addr,
+			ReadTimeout:     srv.ReadTimeout,
+			WriteTimeout:    srv.WriteTimeout,
+			IdleTimeout:     srv.IdleTimeout,
+			MaxHeaderBytes:  srv.MaxHeaderBytes,
+			ShutdownTimeout: srv.ShutdownTimeout,
+			RequestTimeout:  5 * time.Second,
+		},
+		Storage: StorageConfig{
+			Repository:   RepositoryMemory,
+			SnapshotPath: "polls.json",
+			Stripes:      repositories.DefaultStripes,
+		},
+		Limits: LimitsConfig{
+			MaxBodyBytes:       h.MaxBodyBytes,
+			MaxOptions:         20,
+			MaxVotesPerRequest: h.MaxVotesPerRequest,
+			MaxPollsPerStream:  h.MaxPollsPerStream,
+		},
+		Live: LiveConfig{
+			UpdateInterval:    h.UpdateInterval,
+			HeartbeatInterval: h.HeartbeatInterval,
+			RetryDelay:        h.RetryDelay,
+			ResyncInterval:    h.ResyncInterval,
+			MaxWait:           h.MaxWait,
+		},
+		History: HistoryConfig{
+			Interval: 10 * time.Second,
+		},
+		Log: LogConfig{
+			Format: logging.FormatText,
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+		},
+		Events: EventsConfig{
+			Bus:     EventBusInProcess,
+			NATSURL: "nats://localhost:4222",
+			Subject: eventbus.DefaultSubject,
+		},
+	}
+}
+
+// Load builds the configuration for the given command-line arguments. The
+// config file is taken from -config or POLLING_CONFIG.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+
+	// A throwaway parse finds -config before the file is read; the real parse
+	// below re-applies every flag on top of the file and environment.
+	scratch := Default()
+	fs := newFlagSet(&scratch)
+	configPath := fs.String("config", "", "path to a YAML or JSON config file")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if *configPath == "" {
+		*configPath, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return Config{}, err
+		}
+	}
+
+	fs = newFlagSet(&cfg)
+	fs.String("config", *configPath, "path to a YAML or JSON config file")
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := lookupEnv(EnvName(f.Name))
+		if !ok || envErr != nil {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			envErr = fmt.Errorf("invalid %s: %w", EnvName(f.Name), err)
+		}
+	})
+	if envErr != nil {
+		return Config{}, envErr
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// EnvName maps a flag name like "read-timeout" to POLLING_READ_TIMEOUT.
+func EnvName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func newFlagSet(cfg *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("polling-system", flag.ContinueOnError)
+	fs.StringVar(&cfg.Server.Addr, "addr", cfg.Server.Addr, "listen address")
+	fs.DurationVar(&cfg.Server.ReadTimeout, "read-timeout", cfg.Server.ReadTimeout, "maximum duration for reading a request")
+	fs.DurationVar(&cfg.Server.WriteTimeout, "write-timeout", cfg.Server.WriteTimeout, "maximum duration for writing a response (live update streams are exempt)")
+	fs.DurationVar(&cfg.Server.IdleTimeout, "idle-timeout", cfg.Server.IdleTimeout, "maximum time to wait for the next request on a keep-alive connection")
+	fs.IntVar(&cfg.Server.MaxHeaderBytes, "max-header-bytes", cfg.Server.MaxHeaderBytes, "maximum size of request headers")
+	fs.StringVar(&cfg.Server.TLSCertFile, "tls-cert", cfg.Server.TLSCertFile, "TLS certificate file; enables HTTPS together with -tls-key")
+	fs.StringVar(&cfg.Server.TLSKeyFile, "tls-key", cfg.Server.TLSKeyFile, "TLS private key file")
+	fs.StringVar(&cfg.Server.PublicURL, "public-url", cfg.Server.PublicURL, "base URL clients reach the server at, used in link previews (default: taken from each request)")
+	fs.DurationVar(&cfg.Server.ShutdownTimeout, "shutdown-timeout", cfg.Server.ShutdownTimeout, "how long to wait for in-flight requests on shutdown")
+	fs.DurationVar(&cfg.Server.DrainDelay, "drain-delay", cfg.Server.DrainDelay, "how long to keep serving with /readyz failing before shutdown closes the listener")
+	fs.DurationVar(&cfg.Server.RequestTimeout, "request-timeout", cfg.Server.RequestTimeout, "deadline for each non-streaming request; 0 disables it")
+	fs.StringVar(&cfg.Storage.Repository, "repository", cfg.Storage.Repository, `repository implementation: "memory", "snapshot", "sharded", "raft" or "events"`)
+	fs.StringVar(&cfg.Storage.SnapshotPath, "snapshot-path", cfg.Storage.SnapshotPath, "file the snapshot repository loads from and flushes to")
+	fs.StringVar(&cfg.Storage.EventLog, "event-log", cfg.Storage.EventLog, "file the events repository appends poll events to; empty keeps them in memory")
+	fs.IntVar(&cfg.Storage.Stripes, "stripes", cfg.Storage.Stripes, "number of lock stripes of the sharded repository")
+	fs.StringVar(&cfg.Storage.Raft.NodeID, "raft-node-id", cfg.Storage.Raft.NodeID, "ID of this node among the raft peers")
+	fs.Var(&cfg.Storage.Raft.Peers, "raft-peers", "every node of the raft cluster as id@raft_addr@forward_addr, comma-separated")
+	fs.StringVar(&cfg.Storage.Raft.Secret, "raft-secret", cfg.Storage.Raft.Secret, "secret shared by the raft nodes to authenticate forwarded commands")
+	fs.StringVar(&cfg.Receipts.SigningKeyFile, "signing-key-file", cfg.Receipts.SigningKeyFile, "file holding the hex-encoded Ed25519 seed used to sign receipts")
+	fs.StringVar(&cfg.Auth.AdminToken, "admin-token", cfg.Auth.AdminToken, "bearer token required to create polls")
+	fs.Int64Var(&cfg.Limits.MaxBodyBytes, "max-body-bytes", cfg.Limits.MaxBodyBytes, "maximum size of JSON request bodies")
+	fs.IntVar(&cfg.Limits.MaxOptions, "max-options", cfg.Limits.MaxOptions, "maximum number of options per poll")
+	fs.IntVar(&cfg.Limits.MaxVotesPerRequest, "max-votes-per-request", cfg.Limits.MaxVotesPerRequest, "maximum number of votes in one /vote_multiple request")
+	fs.IntVar(&cfg.Limits.MaxPollsPerStream, "max-polls-per-stream", cfg.Limits.MaxPollsPerStream, "maximum number of polls one /poll_updates?ids=... stream may watch")
+	fs.DurationVar(&cfg.Live.UpdateInterval, "update-interval", cfg.Live.UpdateInterval, "shortest time between two results events of a live update stream")
+	fs.DurationVar(&cfg.Live.HeartbeatInterval, "heartbeat-interval", cfg.Live.HeartbeatInterval, "how often idle live update streams send a keep-alive comment")
+	fs.DurationVar(&cfg.Live.RetryDelay, "retry-delay", cfg.Live.RetryDelay, "reconnection delay suggested to live update clients")
+	fs.DurationVar(&cfg.Live.ResyncInterval, "resync-interval", cfg.Live.ResyncInterval, "how often ?mode=delta live update streams send the full tally")
+	fs.DurationVar(&cfg.Live.MaxWait, "max-wait", cfg.Live.MaxWait, "longest a /results/{id}?wait=... long poll may block")
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, `log output format: "text" or "json"`)
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, `minimum log level: "debug", "info", "warn" or "error"`)
+	fs.StringVar(&cfg.Tracing.Exporter, "trace-exporter", cfg.Tracing.Exporter, `span exporter: "none", "stdout", "file" or "otlp"`)
+	fs.StringVar(&cfg.Tracing.File, "trace-file", cfg.Tracing.File, "file the \"file\" span exporter appends to")
+	fs.StringVar(&cfg.Tracing.OTLPEndpoint, "otlp-endpoint", cfg.Tracing.OTLPEndpoint, "OTLP/HTTP collector URL, e.g. http://localhost:4318")
+	fs.Float64Var(&cfg.Tracing.SampleRatio, "trace-sample-ratio", cfg.Tracing.SampleRatio, "fraction of new traces to sample")
+	fs.DurationVar(&cfg.History.Interval, "history-interval", cfg.History.Interval, "how often the tally of polls that changed is recorded for /polls/{id}/history (0 records only on creation and close)")
+	fs.StringVar(&cfg.Events.Bus, "event-bus", cfg.Events.Bus, `how live updates reach other instances: "inprocess" or "nats"`)
+	fs.StringVar(&cfg.Events.NATSURL, "nats-url", cfg.Events.NATSURL, "NATS server the \"nats\" event bus connects to")
+	fs.StringVar(&cfg.Events.Subject, "nats-subject", cfg.Events.Subject, "NATS subject tally changes are published on")
+	return fs
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// YAML is a superset of JSON, so one decoder handles both formats.
+	// Unknown keys are errors, so a misspelled one is not silently ignored.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if err := c.HTTPServer().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("public url %q must be an absolute http or https URL", c.Server.PublicURL))
+		}
+	}
+	switch c.Storage.Repository {
+	case RepositoryMemory:
+	case RepositorySnapshot:
+		if c.Storage.SnapshotPath == "" {
+			errs = append(errs, errors.New("snapshot repository needs a snapshot path"))
+		}
+	case RepositorySharded:
+		if c.Storage.Stripes <= 0 {
+			errs = append(errs, errors.New("sharded repository needs a positive number of stripes"))
+		}
+	case RepositoryEvents:
+	case RepositoryRaft:
+		if err := c.Storage.Raft.validate(); err != nil {
+			errs = append(errs, err)
+		}
+		// Receipts signed with a node's ephemeral key would only verify on
+		// that node, and only until it restarts
+		if c.Receipts.SigningKeyFile == "" {
+			errs = append(errs, errors.New("raft repository needs a signing key file shared by every node"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown repository %q", c.Storage.Repository))
+	}
+	if c.Server.RequestTimeout < 0 {
+		errs = append(errs, errors.New("request timeout must not be negative"))
+	}
+	if c.Limits.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("max body bytes must be positive"))
+	}
+	if c.Limits.MaxOptions < 2 {
+		errs = append(errs, errors.New("max options must be at least 2"))
+	}
+	if c.Limits.MaxVotesPerRequest <= 0 {
+		errs = append(errs, errors.New("max votes per request must be positive"))
+	}
+	if c.Limits.MaxPollsPerStream <= 0 {
+		errs = append(errs, errors.New("max polls per stream must be positive"))
+	}
+	if c.Live.UpdateInterval <= 0 {
+		errs = append(errs, errors.New("update interval must be positive"))
+	}
+	if c.Live.HeartbeatInterval <= 0 {
+		errs = append(errs, errors.New("heartbeat interval must be positive"))
+	}
+	if c.Live.ResyncInterval < 0 {
+		errs = append(errs, errors.New("resync interval must not be negative"))
+	}
+	if c.Live.RetryDelay < 0 {
+		errs = append(errs, errors.New("retry delay must not be negative"))
+	}
+	if c.Live.MaxWait < 0 {
+		errs = append(errs, errors.New("max wait must not be negative"))
+	}
+	if c.History.Interval < 0 {
+		errs = append(errs, errors.New("history interval must not be negative"))
+	}
+	if _, err := c.Logger(io.Discard); err != nil {
+		errs = append(errs, err)
+	}
+	switch c.Events.Bus {
+	case EventBusInProcess:
+	case EventBusNATS:
+		if c.Events.NATSURL == "" || c.Events.Subject == "" {
+			errs = append(errs, errors.New("nats event bus needs a server URL and a subject"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown event bus %q", c.Events.Bus))
+	}
+	if err := c.Tracer().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (c RaftConfig) validate() error {
+	if len(c.Peers) < 3 || len(c.Peers) > 5 {
+		return fmt.Errorf("raft repository needs 3 to 5 peers, got %d", len(c.Peers))
+	}
+	ids := make(map[string]bool, len(c.Peers))
+	for _, peer := range c.Peers {
+		if ids[peer.ID] {
+			return fmt.Errorf("raft peer %q is listed twice", peer.ID)
+		}
+		ids[peer.ID] = true
+	}
+	if !ids[c.NodeID] {
+		return fmt.Errorf("raft node ID %q is not one of the peers", c.NodeID)
+	}
+	if c.Secret == "" {
+		return errors.New("raft repository needs a cluster secret")
+	}
+	return nil
+}
+
+func (c Config) Raft() repositories.RaftConfig {
+	return repositories.RaftConfig{
+		NodeID: c.Storage.Raft.NodeID,
+		Peers:  c.Storage.Raft.Peers,
+		Secret: c.Storage.Raft.Secret,
+	}
+}
+
+func (c Config) HTTPServer() server.Config {
+	return server.Config{
+		Addr:            c.Server.Addr,
+		ReadTimeout:     c.Server.ReadTimeout,
+		WriteTimeout:    c.Server.WriteTimeout,
+		IdleTimeout:     c.Server.IdleTimeout,
+		MaxHeaderBytes:  c.Server.MaxHeaderBytes,
+		TLSCertFile:     c.Server.TLSCertFile,
+		TLSKeyFile:      c.Server.TLSKeyFile,
+		ShutdownTimeout: c.Server.ShutdownTimeout,
+		DrainDelay:      c.Server.DrainDelay,
+	}
+}
+
+func (c Config) Handlers() handlers.Config {
+	return handlers.Config{
+		UpdateInterval:     c.Live.UpdateInterval,
+		HeartbeatInterval:  c.Live.HeartbeatInterval,
+		RetryDelay:         c.Live.RetryDelay,
+		ResyncInterval:     c.Live.ResyncInterval,
+		MaxWait:            c.Live.MaxWait,
+		MaxBodyBytes:       c.Limits.MaxBodyBytes,
+		MaxVotesPerRequest: c.Limits.MaxVotesPerRequest,
+		MaxPollsPerStream:  c.Limits.MaxPollsPerStream,
+		PublicURL:          strings.TrimSuffix(c.Server.PublicURL, "/"),
+	}
+}
+
+func (c Config) Logger(w io.Writer) (*slog.Logger, error) {
+	return logging.New(w, c.Log.Format, c.Log.Level)
+}
+
+func (c Config) Tracer() tracing.Config {
+	return tracing.Config{
+		Exporter:     c.Tracing.Exporter,
+		File:         c.Tracing.File,
+		OTLPEndpoint: c.Tracing.OTLPEndpoint,
+		SampleRatio:  c.Tracing.SampleRatio,
+		ServiceName:  "polling-system",
+	}
+}
+
+// SigningKey loads the receipt signing key, or generates an ephemeral one
+// when no key file is configured.
+func (c Config) SigningKey() (ed25519.PrivateKey, error) {
+	if c.Receipts.SigningKeyFile == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	data, err := os.ReadFile(c.Receipts.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key file %s must hold a hex-encoded %d-byte seed", c.Receipts.SigningKeyFile, ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
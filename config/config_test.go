@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, env(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Server.Addr != ":8080" || cfg.Storage.Repository != RepositoryMemory || cfg.Live.UpdateInterval != 3*time.Second {
+		t.Errorf("Unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	file := `
+server:
+  addr: ":9000"
+  read_timeout: 7s
+storage:
+  repository: snapshot
+  snapshot_path: /var/lib/polls.json
+limits:
+  max_options: 5
+live:
+  update_interval: 1s
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(
+		[]string{"-config", path, "-addr", ":9100"},
+		env(map[string]string{
+			"POLLING_ADDR":            ":9200",
+			"POLLING_UPDATE_INTERVAL": "500ms",
+			"POLLING_ADMIN_TOKEN":     "secret",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Flags beat the environment, which beats the file, which beats defaults
+	if cfg.Server.Addr != ":9100" {
+		t.Errorf("Expected flag to win for addr, got %s", cfg.Server.Addr)
+	}
+	if cfg.Live.UpdateInterval != 500*time.Millisecond {
+		t.Errorf("Expected env to win for update interval, got %s", cfg.Live.UpdateInterval)
+	}
+	if cfg.Server.ReadTimeout != 7*time.Second || cfg.Limits.MaxOptions != 5 || cfg.Storage.Repository != RepositorySnapshot {
+		t.Errorf("Expected file values to apply, got %+v", cfg)
+	}
+	if cfg.Server.WriteTimeout != Default().Server.WriteTimeout {
+		t.Errorf("Expected default write timeout, got %s", cfg.Server.WriteTimeout)
+	}
+	if cfg.Auth.AdminToken != "secret" {
+		t.Errorf("Expected admin token from env, got %q", cfg.Auth.AdminToken)
+	}
+}
+
+func TestLoadJSONFileFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"server": {"addr": ":7000"}, "limits": {"max_votes_per_request": 3}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(nil, env(map[string]string{"POLLING_CONFIG": path}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Server.Addr != ":7000" || cfg.Limits.MaxVotesPerRequest != 3 {
+		t.Errorf("Expected JSON file values to apply, got %+v", cfg)
+	}
+}
+
+func TestLoadFileUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("storage:\n  raft:\n    raft_peer: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load([]string{"-config", path}, env(nil)); err == nil || !strings.Contains(err.Error(), "raft_peer") {
+		t.Errorf("Expected the misspelled key to be reported, got %v", err)
+	}
+}
+
+func TestLoadExampleFile(t *testing.T) {
+	if _, err := Load([]string{"-config", "../config.example.yaml"}, env(nil)); err != nil {
+		t.Errorf("Expected the example config to load, got %v", err)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"unknown repository", []string{"-repository", "postgres"}, nil},
+		{"half TLS", []string{"-tls-cert", "cert.pem"}, nil},
+		{"relative public URL", []string{"-public-url", "polls.example.com"}, nil},
+		{"zero interval", []string{"-update-interval", "0s"}, nil},
+		{"negative history interval", []string{"-history-interval", "-1s"}, nil},
+		{"bad env value", nil, map[string]string{"POLLING_READ_TIMEOUT": "soon"}},
+		{"missing file", []string{"-config", "/does/not/exist.yaml"}, nil},
+		{"bad log format", []string{"-log-format", "xml"}, nil},
+		{"bad log level", nil, map[string]string{"POLLING_LOG_LEVEL": "loud"}},
+		{"bad trace exporter", []string{"-trace-exporter", "zipkin"}, nil},
+		{"bad sample ratio", []string{"-trace-sample-ratio", "2"}, nil},
+		{"raft without peers", []string{"-repository", "raft", "-raft-node-id", "a"}, nil},
+		{"bad raft peer", []string{"-repository", "raft", "-raft-peers", "a@127.0.0.1:7000"}, nil},
+		{"raft node not a peer", []string{"-repository", "raft", "-raft-node-id", "d", "-raft-peers", "a@:7000@:7100,b@:7001@:7101,c@:7002@:7102"}, nil},
+		{"raft without secret", []string{"-repository", "raft", "-raft-node-id", "a", "-raft-peers", "a@:7000@:7100,b@:7001@:7101,c@:7002@:7102", "-signing-key-file", "receipts.key"}, nil},
+		{"raft without signing key", []string{"-repository", "raft", "-raft-node-id", "a", "-raft-peers", "a@:7000@:7100,b@:7001@:7101,c@:7002@:7102", "-raft-secret", "s3cret"}, nil},
+	}
+	for _, tt := range tests {
+		if _, err := Load(tt.args, env(tt.env)); err == nil {
+			t.Errorf("%s: expected an error, got nil", tt.name)
+		}
+	}
+}
+
+func TestLoadRaftPeers(t *testing.T) {
+	cfg, err := Load([]string{"-repository", "raft", "-raft-node-id", "b"}, env(map[string]string{
+		"POLLING_RAFT_PEERS":       "a@10.0.0.1:7000@10.0.0.1:7100, b@10.0.0.2:7000@10.0.0.2:7100,c@10.0.0.3:7000@10.0.0.3:7100",
+		"POLLING_RAFT_SECRET":      "s3cret",
+		"POLLING_SIGNING_KEY_FILE": "receipts.key",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	raft := cfg.Raft()
+	if raft.NodeID != "b" || len(raft.Peers) != 3 || raft.Secret != "s3cret" {
+		t.Fatalf("Expected node b among 3 peers, got %+v", raft)
+	}
+	if peer := raft.Peers[1]; peer.ID != "b" || peer.RaftAddr != "10.0.0.2:7000" || peer.ForwardAddr != "10.0.0.2:7100" {
+		t.Errorf("Expected peer b to be parsed, got %+v", peer)
+	}
+}
+
+func TestSigningKeyFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.key")
+	seed := "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f\n"
+	if err := os.WriteFile(path, []byte(seed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Default()
+	cfg.Receipts.SigningKeyFile = path
+	first, err := cfg.SigningKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _ := cfg.SigningKey()
+	if !first.Equal(second) {
+		t.Error("Expected the same key to be loaded from the same seed")
+	}
+}
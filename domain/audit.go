@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// AuditEntry is one accepted vote, or the retraction of one, in a poll's
+// append-only audit log. Each entry commits to the hash of the one before it,
+// so rewriting any past entry breaks every hash that follows.
+type AuditEntry struct {
+	Seq    int    `json:"seq"`
+	PollID string `json:"poll_id"`
+	Option string `json:"option"`
+	// Retraction marks an entry that takes a vote for Option back out.
+	Retraction bool      `json:"retraction,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+func NewAuditEntry(prev *AuditEntry, vote Vote, at time.Time) AuditEntry {
+	entry := AuditEntry{
+		PollID:    vote.PollID,
+		Option:    vote.Option,
+		Timestamp: at.UTC(),
+	}
+	if prev != nil {
+		entry.Seq = prev.Seq + 1
+		entry.PrevHash = prev.Hash
+	}
+	entry.Hash = entry.ComputeHash()
+	return entry
+}
+
+// NewRetractionEntry records that a vote for vote.Option was retracted.
+func NewRetractionEntry(prev *AuditEntry, vote Vote, at time.Time) AuditEntry {
+	entry := NewAuditEntry(prev, vote, at)
+	entry.Retraction = true
+	entry.Hash = entry.ComputeHash()
+	return entry
+}
+
+func (e AuditEntry) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		strconv.Itoa(e.Seq),
+		e.PollID,
+		e.Option,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+	}
+	// Only retractions hash the flag, so votes keep the hashes they had
+	// before retractions were logged
+	if e.Retraction {
+		fields = append(fields, "retraction")
+	}
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyAuditLog checks that entries form an unbroken chain for pollID and
+// returns the tallies recomputed from them, with retractions subtracted.
+func VerifyAuditLog(pollID string, entries []AuditEntry) (map[string]int, error) {
+	tallies := make(map[string]int)
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Seq != i {
+			return nil, fmt.Errorf("entry %d: unexpected sequence number %d", i, entry.Seq)
+		}
+		if entry.PollID != pollID {
+			return nil, fmt.Errorf("entry %d: belongs to poll %s", i, entry.PollID)
+		}
+		if entry.PrevHash != prevHash {
+			return nil, fmt.Errorf("entry %d: previous hash does not match entry %d", i, i-1)
+		}
+		if entry.ComputeHash() != entry.Hash {
+			return nil, fmt.Errorf("entry %d: hash mismatch", i)
+		}
+		if entry.Retraction {
+			if tallies[entry.Option] == 0 {
+				return nil, fmt.Errorf("entry %d: retracts a vote for %q that was never cast", i, entry.Option)
+			}
+			if tallies[entry.Option]--; tallies[entry.Option] == 0 {
+				delete(tallies, entry.Option)
+			}
+		} else {
+			tallies[entry.Option]++
+		}
+		prevHash = entry.Hash
+	}
+	return tallies, nil
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPollNotFound  = errors.New("poll not found")
+	ErrInvalidOption = errors.New("invalid option")
+	ErrPollClosed    = errors.New("poll is closed")
+	ErrPollExists    = errors.New("poll already exists")
+	ErrNoVote        = errors.New("no vote to retract")
+	// ErrNotBallotOwner means a retraction did not carry the nonce that
+	// opens the voter's ballot, so it may come from someone else.
+	ErrNotBallotOwner = errors.New("nonce does not open the voter's ballot")
+	// ErrInvalidPoll means a poll to create is missing its ID, has too few
+	// options or more than the server allows.
+	ErrInvalidPoll = errors.New("invalid poll")
+	// ErrStreamConflict means a poll's event stream changed between loading
+	// it and appending to it.
+	ErrStreamConflict = errors.New("poll event stream changed concurrently")
+	// ErrNoHistory is returned for history operations on a repository that
+	// only keeps current state.
+	ErrNoHistory = fmt.Errorf("repository keeps no poll history: %w", errors.ErrUnsupported)
+)
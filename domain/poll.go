@@ -1,21 +1,48 @@
 package domain
 
+import "time"
+
 type Poll struct {
 	ID       string
 	Question string
 	Options  []string
+	// CreatedAt is set by the service when the poll is created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// ClosesAt is when the poll stops accepting votes; nil keeps it open.
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+}
+
+// Open reports whether the poll still accepts votes at the given time.
+func (p Poll) Open(at time.Time) bool {
+	return p.ClosesAt == nil || at.Before(*p.ClosesAt)
 }
 
 type Vote struct {
-	PollID string `json:"poll_id"`
-	Option string `json:"option"`
+	PollID  string `json:"poll_id"`
+	Option  string `json:"option"`
+	VoterID string `json:"voter_id,omitempty"`
 }
 
 type MultiVote struct {
 	Votes []Vote `json:"votes"`
 }
 
+// PollResult is a snapshot of a poll's tally. It shares no memory with the
+// repository, so it stays unchanged while further votes come in.
 type PollResult struct {
 	Poll    Poll
 	Results map[string]int
+	// Version grows by one with every vote applied to the tally; results of
+	// the same poll with equal versions hold equal counts.
+	Version    uint64
+	CapturedAt time.Time
+	// UpdatedAt is when the latest vote was applied; zero before the first.
+	UpdatedAt time.Time
+}
+
+// TallyChanged announces that a poll's tally has changed. Origin identifies
+// the service instance that applied the change.
+type TallyChanged struct {
+	PollID string `json:"poll_id"`
+	Origin string `json:"origin"`
 }
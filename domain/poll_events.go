@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Types of PollEvent.
+const (
+	PollCreated   = "poll_created"
+	VoteCast      = "vote_cast"
+	VoteRetracted = "vote_retracted"
+	PollClosed    = "poll_closed"
+	// CommitmentAdded recorded a receipt's ballot commitment separately from
+	// its vote. VoteCast carries the commitment now; streams written before
+	// that still replay.
+	CommitmentAdded = "commitment_added"
+	// TallySnapshotted records a TallySnapshot for the poll's tally history.
+	TallySnapshotted = "tally_snapshotted"
+)
+
+// PollEvent is one change in a poll's history. A poll's events form a stream
+// numbered from 0 that starts with PollCreated; its state at any moment is
+// what replaying the stream up to then yields.
+type PollEvent struct {
+	Seq    int       `json:"seq"`
+	Type   string    `json:"type"`
+	PollID string    `json:"poll_id"`
+	At     time.Time `json:"at"`
+	// Poll is set on PollCreated.
+	Poll *Poll `json:"poll,omitempty"`
+	// Option and VoterID are set on VoteCast and VoteRetracted.
+	Option  string `json:"option,omitempty"`
+	VoterID string `json:"voter_id,omitempty"`
+	// Commitment is the receipt commitment of the ballot a VoteCast counts or
+	// a VoteRetracted withdraws.
+	Commitment string `json:"commitment,omitempty"`
+	// Snapshot is set on TallySnapshotted.
+	Snapshot *TallySnapshot `json:"snapshot,omitempty"`
+}
+
+// PollAggregate is the write side of an event-sourced poll: it checks a
+// command against the state replayed from past events and returns the
+// event that records it. Apply the event once it is stored.
+type PollAggregate struct {
+	id      string
+	poll    *Poll
+	closed  bool
+	next    int
+	ballots map[string][]castBallot
+}
+
+// castBallot is a counted vote of a voter and the commitment it was cast with.
+type castBallot struct {
+	option     string
+	commitment string
+}
+
+func NewPollAggregate(id string) *PollAggregate {
+	return &PollAggregate{id: id, ballots: make(map[string][]castBallot)}
+}
+
+// Version is the number of events applied so far, i.e. the Seq of the next.
+func (a *PollAggregate) Version() int {
+	return a.next
+}
+
+func (a *PollAggregate) Create(poll Poll, at time.Time) (PollEvent, error) {
+	if a.poll != nil {
+		return PollEvent{}, fmt.Errorf("%w: %s", ErrPollExists, a.id)
+	}
+	poll.Options = slices.Clone(poll.Options)
+	if poll.CreatedAt == nil {
+		created := at.UTC()
+		poll.CreatedAt = &created
+	}
+	return a.event(PollCreated, at, PollEvent{Poll: &poll}), nil
+}
+
+// Cast counts the vote together with the commitment of its receipt.
+func (a *PollAggregate) Cast(vote Vote, commitment string, at time.Time) (PollEvent, error) {
+	if err := a.open(at); err != nil {
+		return PollEvent{}, err
+	}
+	if !slices.Contains(a.poll.Options, vote.Option) {
+		return PollEvent{}, fmt.Errorf("%w %q for poll %s", ErrInvalidOption, vote.Option, a.id)
+	}
+	return a.event(VoteCast, at, PollEvent{Option: vote.Option, VoterID: vote.VoterID, Commitment: commitment}), nil
+}
+
+// Retract withdraws the latest vote the voter cast that is still counted.
+// The nonce of that vote's ballot proves the retraction comes from the voter.
+func (a *PollAggregate) Retract(voterID, nonce string, at time.Time) (PollEvent, error) {
+	if err := a.open(at); err != nil {
+		return PollEvent{}, err
+	}
+	ballots := a.ballots[voterID]
+	if voterID == "" || len(ballots) == 0 {
+		return PollEvent{}, fmt.Errorf("%w for voter %q in poll %s", ErrNoVote, voterID, a.id)
+	}
+	last := ballots[len(ballots)-1]
+	if last.commitment == "" || BallotCommitment(Vote{PollID: a.id, Option: last.option}, nonce) != last.commitment {
+		return PollEvent{}, fmt.Errorf("%w: voter %q in poll %s", ErrNotBallotOwner, voterID, a.id)
+	}
+	return a.event(VoteRetracted, at, PollEvent{Option: last.option, VoterID: voterID, Commitment: last.commitment}), nil
+}
+
+func (a *PollAggregate) Close(at time.Time) (PollEvent, error) {
+	if err := a.open(at); err != nil {
+		return PollEvent{}, err
+	}
+	return a.event(PollClosed, at, PollEvent{}), nil
+}
+
+// RecordSnapshot is allowed after the poll closed, so its final tally can
+// be recorded too.
+func (a *PollAggregate) RecordSnapshot(snap TallySnapshot, at time.Time) (PollEvent, error) {
+	if a.poll == nil {
+		return PollEvent{}, ErrPollNotFound
+	}
+	snap.Results = maps.Clone(snap.Results)
+	return a.event(TallySnapshotted, at, PollEvent{Snapshot: &snap}), nil
+}
+
+func (a *PollAggregate) open(at time.Time) error {
+	if a.poll == nil {
+		return ErrPollNotFound
+	}
+	if a.closed || !a.poll.Open(at) {
+		return fmt.Errorf("%w: %s", ErrPollClosed, a.id)
+	}
+	return nil
+}
+
+func (a *PollAggregate) event(typ string, at time.Time, e PollEvent) PollEvent {
+	e.Seq = a.next
+	e.Type = typ
+	e.PollID = a.id
+	e.At = at.UTC()
+	return e
+}
+
+// Apply advances the aggregate past an event of its stream.
+func (a *PollAggregate) Apply(e PollEvent) error {
+	if e.Seq != a.next {
+		return fmt.Errorf("poll %s: expected event %d, got %d", a.id, a.next, e.Seq)
+	}
+	switch e.Type {
+	case PollCreated:
+		poll := *e.Poll
+		a.poll = &poll
+	case VoteCast:
+		if e.VoterID != "" {
+			a.ballots[e.VoterID] = append(a.ballots[e.VoterID], castBallot{option: e.Option, commitment: e.Commitment})
+		}
+	case VoteRetracted:
+		if ballots := a.ballots[e.VoterID]; len(ballots) > 0 {
+			a.ballots[e.VoterID] = ballots[:len(ballots)-1]
+		}
+	case PollClosed:
+		a.closed = true
+	}
+	a.next++
+	return nil
+}
+
+// TallyProjection is the read model of a poll's results, built by applying
+// its events in order.
+type TallyProjection struct {
+	poll      Poll
+	tally     map[string]int
+	version   uint64
+	updatedAt time.Time
+}
+
+func NewTallyProjection() *TallyProjection {
+	return &TallyProjection{tally: make(map[string]int)}
+}
+
+func (p *TallyProjection) Apply(e PollEvent) {
+	switch e.Type {
+	case PollCreated:
+		p.poll = *e.Poll
+	case VoteCast:
+		p.tally[e.Option]++
+		p.version++
+		p.updatedAt = e.At
+	case VoteRetracted:
+		if p.tally[e.Option]--; p.tally[e.Option] == 0 {
+			delete(p.tally, e.Option)
+		}
+		p.version++
+		p.updatedAt = e.At
+	case PollClosed:
+		// Closing changes the result too, so it gets a version of its own
+		if p.poll.Open(e.At) {
+			at := e.At
+			p.poll.ClosesAt = &at
+		}
+		p.version++
+		p.updatedAt = e.At
+	}
+}
+
+// Result returns the tally as of the last applied event.
+func (p *TallyProjection) Result() PollResult {
+	poll := p.poll
+	poll.Options = slices.Clone(poll.Options)
+	return PollResult{
+		Poll:       poll,
+		Results:    maps.Clone(p.tally),
+		Version:    p.version,
+		CapturedAt: time.Now(),
+		UpdatedAt:  p.updatedAt,
+	}
+}
+
+// ProjectTally replays the events that happened no later than at, so the
+// result is the tally as it stood then.
+func ProjectTally(events []PollEvent, at time.Time) (PollResult, error) {
+	if len(events) == 0 {
+		return PollResult{}, ErrPollNotFound
+	}
+	if at.Before(events[0].At) {
+		return PollResult{}, fmt.Errorf("%w: poll %s was created at %s", ErrPollNotFound, events[0].PollID, events[0].At.Format(time.RFC3339))
+	}
+	projection := NewTallyProjection()
+	for _, e := range events {
+		if e.At.After(at) {
+			break
+		}
+		projection.Apply(e)
+	}
+	result := projection.Result()
+	result.CapturedAt = at
+	return result, nil
+}
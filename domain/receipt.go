@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Receipt is handed to a voter after a successful vote. The commitment hides
+// the chosen option behind a random nonce, so the receipt can be shown to
+// others without revealing the ballot.
+type Receipt struct {
+	PollID     string `json:"poll_id"`
+	Commitment string `json:"commitment"`
+	Signature  string `json:"signature"`
+}
+
+// Ballot is what the voter gets back for a vote: the shareable receipt and
+// the nonce that opens its commitment. The nonce is private to the voter;
+// with it anyone could recompute which option the commitment hides.
+type Ballot struct {
+	Receipt Receipt `json:"receipt"`
+	Nonce   string  `json:"nonce"`
+}
+
+// BallotCommitment is the hash a voter can recompute from their ballot nonce
+// and the option they picked to check the receipt matches their ballot.
+func BallotCommitment(vote Vote, nonce string) string {
+	sum := sha256.Sum256([]byte(vote.PollID + "\x00" + vote.Option + "\x00" + nonce))
+	return hex.EncodeToString(sum[:])
+}
+
+// TallyCommitment is the published Merkle root over every ballot commitment
+// accepted for a poll.
+type TallyCommitment struct {
+	PollID    string `json:"poll_id"`
+	Size      int    `json:"size"`
+	Root      string `json:"root"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// ReceiptVerification reports whether a receipt's commitment is in the tally.
+// Leaves are ordered by commitment, so Index is the position the proof is for
+// and carries no information about when the ballot was cast.
+type ReceiptVerification struct {
+	Valid  bool            `json:"valid"`
+	Reason string          `json:"reason,omitempty"`
+	Index  int             `json:"index"`
+	Proof  []string        `json:"proof,omitempty"`
+	Tally  TallyCommitment `json:"tally"`
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"maps"
+	"time"
+)
+
+// TallySnapshot is a poll's per-option counts as they stood at one moment.
+// A poll's snapshots, oldest first, chart how its results evolved.
+type TallySnapshot struct {
+	At      time.Time      `json:"at"`
+	Version uint64         `json:"version"`
+	Results map[string]int `json:"results"`
+	// Final marks the snapshot recorded when the poll closed.
+	Final bool `json:"final,omitempty"`
+}
+
+// NewTallySnapshot captures result at the given time, with a count for
+// every option of the poll so that each series of a chart is complete.
+func NewTallySnapshot(result PollResult, at time.Time) TallySnapshot {
+	results := make(map[string]int, len(result.Poll.Options))
+	for _, option := range result.Poll.Options {
+		results[option] = 0
+	}
+	maps.Copy(results, result.Results)
+	return TallySnapshot{At: at.UTC(), Version: result.Version, Results: results}
+}
+
+// TallyHistory is the time series of a poll's tally, oldest point first.
+type TallyHistory struct {
+	PollID  string          `json:"poll_id"`
+	Options []string        `json:"options"`
+	Points  []TallySnapshot `json:"points"`
+}
+
+// DownsampleHistory thins a time series of snapshots to at most one per
+// step, keeping the latest of each window measured from the first snapshot.
+// With maxPoints set, step is widened until at most that many remain. The
+// newest snapshot is always kept, so the series ends at the current tally.
+func DownsampleHistory(history []TallySnapshot, step time.Duration, maxPoints int) []TallySnapshot {
+	if len(history) == 0 {
+		return history
+	}
+	if maxPoints > 0 && len(history) > maxPoints {
+		span := history[len(history)-1].At.Sub(history[0].At)
+		if widest := span/time.Duration(maxPoints) + 1; step < widest {
+			step = widest
+		}
+	}
+	if step <= 0 {
+		return history
+	}
+
+	start := history[0].At
+	var out []TallySnapshot
+	for i, snap := range history {
+		if i+1 < len(history) && history[i+1].At.Sub(start)/step == snap.At.Sub(start)/step {
+			continue
+		}
+		out = append(out, snap)
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+// Package httpx holds the HTTP plumbing shared by the middleware adapters.
+package httpx
+
+import "net/http"
+
+// Recorder wraps a ResponseWriter to remember the status code and body size
+// of the response, for middleware that reports on requests. It passes Flush
+// through so live update streams keep working behind it.
+type Recorder struct {
+	http.ResponseWriter
+	Status int
+	Bytes  int64
+	// OnHeader, if set, is called once with the status when the header is
+	// sent, which for a stream is when it opens.
+	OnHeader    func(status int)
+	wroteHeader bool
+}
+
+// NewRecorder returns a Recorder whose status is 200 until the handler
+// writes another.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *Recorder) WriteHeader(code int) {
+	r.sent(code)
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.sent(http.StatusOK)
+	n, err := r.ResponseWriter.Write(b)
+	r.Bytes += int64(n)
+	return n, err
+}
+
+func (r *Recorder) Flush() {
+	r.sent(http.StatusOK)
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sent records the status of the header unless one was already sent.
+func (r *Recorder) sent(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.Status = code
+	r.wroteHeader = true
+	if r.OnHeader != nil {
+		r.OnHeader(code)
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
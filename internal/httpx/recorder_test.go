@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rec := NewRecorder(rr)
+	rec.WriteHeader(http.StatusNotFound)
+	rec.WriteHeader(http.StatusOK)
+	_, _ = rec.Write([]byte("missing"))
+	rec.Flush()
+
+	if rec.Status != http.StatusNotFound {
+		t.Errorf("Expected the first status 404, got %d", rec.Status)
+	}
+	if rec.Bytes != 7 {
+		t.Errorf("Expected 7 bytes, got %d", rec.Bytes)
+	}
+	if !rr.Flushed {
+		t.Error("Expected Flush to reach the underlying writer")
+	}
+	if http.NewResponseController(rec).Flush() != nil {
+		t.Error("Expected a ResponseController to unwrap the recorder")
+	}
+}
+
+func TestRecorderImplicitStatus(t *testing.T) {
+	rec := NewRecorder(httptest.NewRecorder())
+	_, _ = rec.Write([]byte("ok"))
+	rec.WriteHeader(http.StatusInternalServerError)
+	if rec.Status != http.StatusOK {
+		t.Errorf("Expected the implicit 200 to stick, got %d", rec.Status)
+	}
+}
+
+func TestRecorderOnHeader(t *testing.T) {
+	var statuses []int
+	rec := NewRecorder(httptest.NewRecorder())
+	rec.OnHeader = func(status int) { statuses = append(statuses, status) }
+	rec.Flush()
+	_, _ = rec.Write([]byte("data: 1\n\n"))
+	rec.WriteHeader(http.StatusNoContent)
+	if len(statuses) != 1 || statuses[0] != http.StatusOK {
+		t.Errorf("Expected one call with 200, got %v", statuses)
+	}
+}
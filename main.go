@@ -1,25 +1,217 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"polling-system/adapters/eventbus"
+	"polling-system/adapters/eventstore"
 	"polling-system/adapters/handlers"
+	"polling-system/adapters/logging"
+	"polling-system/adapters/metrics"
 	"polling-system/adapters/repositories"
+	"polling-system/adapters/server"
 	"polling-system/adapters/services"
+	"polling-system/adapters/tracing"
+	"polling-system/adapters/web"
+	"polling-system/config"
+	"polling-system/ports"
 )
 
 func main() {
-	repo := repositories.NewMemoryRepository()
-	pollService := services.NewPollService(repo)
-	handler := handlers.NewHTTPHandler(pollService)
-
-	http.HandleFunc("/create_poll", handler.CreatePollHandler)
-	http.HandleFunc("/vote", handler.VoteHandler)
-	http.HandleFunc("/vote_multiple", handler.VoteMultipleHandler)
-	http.HandleFunc("/results/{id}", handler.ResultsHandler)
-	http.HandleFunc("/poll_updates/{id}", handler.PollUpdatesHandler)
-
-	log.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracer())
+	if err != nil {
+		fatal(logger, "failed to set up tracing", err)
+	}
+
+	signer, err := cfg.SigningKey()
+	if err != nil {
+		fatal(logger, "failed to load receipt signing key", err)
+	}
+
+	base, err := newRepository(cfg)
+	if err != nil {
+		fatal(logger, "failed to open repository", err)
+	}
+
+	m := metrics.New()
+	var repo ports.PollRepository = metrics.NewPollRepository(base, m)
+	repo = tracing.NewPollRepository(repo)
+
+	events, err := newEventBus(cfg.Events)
+	if err != nil {
+		fatal(logger, "failed to connect the event bus", err)
+	}
+
+	service := services.NewPollService(repo, services.Config{
+		SigningKey:      signer,
+		MaxOptions:      cfg.Limits.MaxOptions,
+		Events:          events,
+		HistoryInterval: cfg.History.Interval,
+	})
+	stopListening, err := service.Listen()
+	if err != nil {
+		fatal(logger, "failed to subscribe to the event bus", err)
+	}
+	stopRecording := service.RecordHistory()
+
+	var pollService ports.PollService = service
+	pollService = logging.NewPollService(pollService, logger)
+	pollService = metrics.NewPollService(pollService, m)
+	pollService = tracing.NewPollService(pollService)
+	handler := handlers.NewHTTPHandler(pollService, cfg.Handlers())
+
+	mux := http.NewServeMux()
+	stream := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, m.InstrumentRoute(pattern, tracing.InstrumentRoute(pattern, h)))
+	}
+	handle := func(pattern string, h http.HandlerFunc) {
+		stream(pattern, handlers.WithTimeout(cfg.Server.RequestTimeout, h))
+	}
+	handle("/create_poll", handlers.RequireToken(cfg.Auth.AdminToken, handler.CreatePollHandler))
+	handle("/vote", handler.VoteHandler)
+	handle("/vote_multiple", handler.VoteMultipleHandler)
+	// Long polls may block for up to max-wait on top of the usual deadline
+	resultsTimeout := cfg.Server.RequestTimeout
+	if resultsTimeout > 0 {
+		resultsTimeout += cfg.Live.MaxWait
+	}
+	stream("/results/{id}", handlers.WithTimeout(resultsTimeout, handler.ResultsHandler))
+	stream("/poll_updates/{id}", m.TrackSubscribers(handler.PollUpdatesHandler))
+	stream("/poll_updates", m.TrackSubscribers(handler.MultiPollUpdatesHandler))
+	handle("/polls/{id}/commitment", handler.TallyCommitmentHandler)
+	handle("/polls/{id}/receipts/verify", handler.VerifyReceiptHandler)
+	handle("/polls/{id}/audit", handler.AuditHandler)
+	handle("/polls/{id}/events", handlers.RequireToken(cfg.Auth.AdminToken, handler.PollEventsHandler))
+	handle("/polls/{id}/close", handlers.RequireToken(cfg.Auth.AdminToken, handler.ClosePollHandler))
+	handle("/polls/{id}/retract", handler.RetractVoteHandler)
+	handle("/polls/{id}/history", handler.TallyHistoryHandler)
+	handle("/polls/{id}/chart.svg", handler.ChartHandler)
+	handle("/polls/{id}/card.png", handler.CardHandler)
+	handle("/polls/{id}/qr.svg", handler.QRHandler)
+	handle("/polls/{id}/qr.png", handler.QRHandler)
+	handle("/polls/{id}", handler.PollPageHandler)
+	handle("/ui/", http.StripPrefix("/ui", web.Handler()).ServeHTTP)
+	mux.Handle("/{$}", http.RedirectHandler("/ui/", http.StatusFound))
+	mux.Handle("/metrics", m.Handler())
+
+	var srv *server.Server
+	health := handlers.NewHealthHandler()
+	health.AddCheck("repository", func(ctx context.Context) error {
+		if checker, ok := repo.(ports.ReadinessChecker); ok {
+			return checker.Ready(ctx)
+		}
+		return nil
+	})
+	health.AddCheck("event bus", func(ctx context.Context) error {
+		if checker, ok := events.(ports.ReadinessChecker); ok {
+			return checker.Ready(ctx)
+		}
+		return nil
+	})
+	health.AddCheck("draining", func(ctx context.Context) error {
+		if srv.Draining() {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	})
+	mux.HandleFunc("/healthz", health.Healthz)
+	mux.HandleFunc("/readyz", handlers.WithTimeout(cfg.Server.RequestTimeout, health.Readyz))
+	mux.HandleFunc("/version", health.Version)
+
+	srv = server.New(cfg.HTTPServer(), logging.Middleware(logger, mux))
+	srv.OnShutdown(handler.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("server starting", "addr", cfg.Server.Addr, "tls", cfg.HTTPServer().TLSEnabled(), "repository", cfg.Storage.Repository)
+	runErr := srv.Run(ctx)
+	if runErr != nil {
+		logger.Error("server error", "error", runErr)
+	}
+	logger.Info("server stopped")
+
+	stopListening()
+	stopRecording()
+	if err := events.Close(); err != nil {
+		logger.Error("failed to close the event bus", "error", err)
+	}
+
+	if flusher, ok := repo.(ports.Flusher); ok {
+		if err := flusher.Flush(); err != nil {
+			fatal(logger, "failed to flush repository", err)
+		}
+	}
+	if closer, ok := base.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Error("failed to close repository", "error", err)
+		}
+	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+	if runErr != nil {
+		os.Exit(1)
+	}
+}
+
+func newRepository(cfg config.Config) (ports.PollRepository, error) {
+	switch cfg.Storage.Repository {
+	case config.RepositorySnapshot:
+		return repositories.NewSnapshotRepository(cfg.Storage.SnapshotPath)
+	case config.RepositorySharded:
+		return repositories.NewShardedRepository(cfg.Storage.Stripes), nil
+	case config.RepositoryRaft:
+		return repositories.NewRaftRepository(cfg.Raft())
+	case config.RepositoryEvents:
+		if cfg.Storage.EventLog == "" {
+			return repositories.NewEventSourcedRepository(eventstore.NewMemory()), nil
+		}
+		store, err := eventstore.NewFile(cfg.Storage.EventLog)
+		if err != nil {
+			return nil, err
+		}
+		return repositories.NewEventSourcedRepository(store), nil
+	default:
+		return repositories.NewMemoryRepository(), nil
+	}
+}
+
+func newEventBus(cfg config.EventsConfig) (ports.EventBus, error) {
+	switch cfg.Bus {
+	case config.EventBusNATS:
+		return eventbus.NewNATS(cfg.NATSURL, cfg.Subject)
+	default:
+		return eventbus.NewInProcess(), nil
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -1,54 +1,288 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	"polling-system/domain"
 )
 
 type MockPollService struct {
-	polls map[string]*domain.Poll
-	votes map[string]map[string]int
+	mu       sync.Mutex
+	polls    map[string]*domain.Poll
+	votes    map[string]map[string]int
+	receipts map[string][]string
+	audit    map[string][]domain.AuditEntry
+	changes  map[string]chan struct{}
+	history  map[string]*domain.PollAggregate
+	events   map[string][]domain.PollEvent
 }
 
 func NewMockPollService() *MockPollService {
 	return &MockPollService{
-		polls: make(map[string]*domain.Poll),
-		votes: make(map[string]map[string]int),
+		polls:    make(map[string]*domain.Poll),
+		votes:    make(map[string]map[string]int),
+		receipts: make(map[string][]string),
+		audit:    make(map[string][]domain.AuditEntry),
+		changes:  make(map[string]chan struct{}),
+		history:  make(map[string]*domain.PollAggregate),
+		events:   make(map[string][]domain.PollEvent),
 	}
 }
 
-func (m *MockPollService) CreatePoll(poll domain.Poll) error {
+func (m *MockPollService) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.polls[poll.ID] = &poll
 	m.votes[poll.ID] = make(map[string]int)
+	m.history[poll.ID] = domain.NewPollAggregate(poll.ID)
+	m.events[poll.ID] = nil
+	created := poll
+	e := domain.PollEvent{Type: domain.PollCreated, PollID: poll.ID, Poll: &created}
+	if poll.CreatedAt != nil {
+		e.At = *poll.CreatedAt
+	}
+	m.record(e)
 	return nil
 }
 
-func (m *MockPollService) Vote(vote domain.Vote) error {
+func (m *MockPollService) Vote(ctx context.Context, vote domain.Vote) (domain.Ballot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return domain.Ballot{}, err
+	}
 	if _, ok := m.votes[vote.PollID]; !ok {
-		return fmt.Errorf("poll not found")
+		return domain.Ballot{}, domain.ErrPollNotFound
+	}
+	if !slices.Contains(m.polls[vote.PollID].Options, vote.Option) {
+		return domain.Ballot{}, fmt.Errorf("%w %q for poll %s", domain.ErrInvalidOption, vote.Option, vote.PollID)
 	}
 	m.votes[vote.PollID][vote.Option]++
-	return nil
+	var prev *domain.AuditEntry
+	if log := m.audit[vote.PollID]; len(log) > 0 {
+		prev = &log[len(log)-1]
+	}
+	m.audit[vote.PollID] = append(m.audit[vote.PollID], domain.NewAuditEntry(prev, vote, time.Now()))
+	nonce := fmt.Sprintf("nonce-%d", len(m.receipts[vote.PollID]))
+	commitment := domain.BallotCommitment(vote, nonce)
+	m.receipts[vote.PollID] = append(m.receipts[vote.PollID], commitment)
+	m.record(domain.PollEvent{Type: domain.VoteCast, PollID: vote.PollID, Option: vote.Option, VoterID: vote.VoterID, Commitment: commitment})
+	m.changed(vote.PollID)
+	return domain.Ballot{Receipt: domain.Receipt{PollID: vote.PollID, Commitment: commitment}, Nonce: nonce}, nil
 }
 
-func (m *MockPollService) VoteMultiple(multiVote domain.MultiVote) error {
+func (m *MockPollService) VoteMultiple(ctx context.Context, multiVote domain.MultiVote) ([]domain.Ballot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var ballots []domain.Ballot
 	for _, vote := range multiVote.Votes {
-		err := m.Vote(vote)
+		ballot, err := m.Vote(ctx, vote)
 		if err != nil {
-			return err
+			return ballots, err
 		}
+		ballots = append(ballots, ballot)
 	}
-	return nil
+	return ballots, nil
 }
 
-func (m *MockPollService) GetResults(pollID string) (domain.PollResult, error) {
+func (m *MockPollService) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return domain.PollResult{}, err
+	}
 	poll, ok := m.polls[pollID]
 	if !ok {
-		return domain.PollResult{}, fmt.Errorf("poll not found")
+		return domain.PollResult{}, domain.ErrPollNotFound
 	}
-	return domain.PollResult{
-		Poll:    *poll,
-		Results: m.votes[pollID],
+	result := domain.PollResult{
+		Poll:       *poll,
+		Results:    maps.Clone(m.votes[pollID]),
+		CapturedAt: time.Now(),
+	}
+	for _, e := range m.events[pollID] {
+		if e.Type == domain.VoteCast || e.Type == domain.VoteRetracted || e.Type == domain.PollClosed {
+			result.Version++
+			result.UpdatedAt = e.At
+		}
+	}
+	return result, nil
+}
+
+func (m *MockPollService) TallyCommitment(ctx context.Context, pollID string) (domain.TallyCommitment, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.TallyCommitment{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.polls[pollID]; !ok {
+		return domain.TallyCommitment{}, domain.ErrPollNotFound
+	}
+	return domain.TallyCommitment{PollID: pollID, Size: len(m.receipts[pollID])}, nil
+}
+
+func (m *MockPollService) VerifyReceipt(ctx context.Context, receipt domain.Receipt) (domain.ReceiptVerification, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.ReceiptVerification{}, err
+	}
+	tally, err := m.TallyCommitment(ctx, receipt.PollID)
+	if err != nil {
+		return domain.ReceiptVerification{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return domain.ReceiptVerification{
+		Valid: slices.Contains(m.receipts[receipt.PollID], receipt.Commitment),
+		Tally: tally,
 	}, nil
 }
+
+func (m *MockPollService) AuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := m.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	return m.audit[pollID], nil
+}
+
+func (m *MockPollService) ResultsChanged(ctx context.Context, pollID string) (<-chan struct{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	ch, ok := m.changes[pollID]
+	if !ok {
+		ch = make(chan struct{})
+		m.changes[pollID] = ch
+	}
+	return ch, nil
+}
+
+func (m *MockPollService) ClosePoll(ctx context.Context, pollID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history, ok := m.history[pollID]
+	if !ok {
+		return domain.ErrPollNotFound
+	}
+	e, err := history.Close(time.Now())
+	if err != nil {
+		return err
+	}
+	m.record(e)
+	closesAt := e.At
+	m.polls[pollID].ClosesAt = &closesAt
+	m.changed(pollID)
+	return nil
+}
+
+func (m *MockPollService) RetractVote(ctx context.Context, pollID, voterID, nonce string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history, ok := m.history[pollID]
+	if !ok {
+		return domain.ErrPollNotFound
+	}
+	e, err := history.Retract(voterID, nonce, time.Now())
+	if err != nil {
+		return err
+	}
+	m.record(e)
+	m.votes[pollID][e.Option]--
+	if i := slices.Index(m.receipts[pollID], e.Commitment); i >= 0 {
+		m.receipts[pollID] = slices.Delete(m.receipts[pollID], i, i+1)
+	}
+	var prev *domain.AuditEntry
+	if log := m.audit[pollID]; len(log) > 0 {
+		prev = &log[len(log)-1]
+	}
+	m.audit[pollID] = append(m.audit[pollID], domain.NewRetractionEntry(prev, domain.Vote{PollID: pollID, Option: e.Option}, e.At))
+	m.changed(pollID)
+	return nil
+}
+
+func (m *MockPollService) ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error) {
+	events, err := m.PollEvents(ctx, pollID)
+	if err != nil {
+		return domain.PollResult{}, err
+	}
+	return domain.ProjectTally(events, at)
+}
+
+func (m *MockPollService) PollEvents(ctx context.Context, pollID string) ([]domain.PollEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	return slices.Clone(m.events[pollID]), nil
+}
+
+// TallyHistory replays the poll's events with a snapshot after every change
+// to its tally.
+func (m *MockPollService) TallyHistory(ctx context.Context, pollID string, step time.Duration, maxPoints int) (domain.TallyHistory, error) {
+	events, err := m.PollEvents(ctx, pollID)
+	if err != nil {
+		return domain.TallyHistory{}, err
+	}
+	projection := domain.NewTallyProjection()
+	var points []domain.TallySnapshot
+	for _, e := range events {
+		projection.Apply(e)
+		switch e.Type {
+		case domain.PollCreated, domain.VoteCast, domain.VoteRetracted, domain.PollClosed:
+			snap := domain.NewTallySnapshot(projection.Result(), e.At)
+			snap.Final = e.Type == domain.PollClosed
+			points = append(points, snap)
+		}
+	}
+	return domain.TallyHistory{
+		PollID:  pollID,
+		Options: projection.Result().Poll.Options,
+		Points:  domain.DownsampleHistory(points, step, maxPoints),
+	}, nil
+}
+
+// record appends an event to the poll's history with m.mu held.
+func (m *MockPollService) record(e domain.PollEvent) {
+	e.Seq = m.history[e.PollID].Version()
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	_ = m.history[e.PollID].Apply(e)
+	m.events[e.PollID] = append(m.events[e.PollID], e)
+}
+
+// changed wakes ResultsChanged waiters with m.mu held.
+func (m *MockPollService) changed(pollID string) {
+	if ch, ok := m.changes[pollID]; ok {
+		close(ch)
+		delete(m.changes, pollID)
+	}
+}
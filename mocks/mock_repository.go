@@ -1,52 +1,126 @@
 package mocks
 
 import (
-	"fmt"
+	"context"
+	"maps"
+	"time"
 
 	"polling-system/domain"
 )
 
 type MockRepository struct {
-	polls map[string]*domain.Poll
-	votes map[string]map[string]int
+	polls       map[string]*domain.Poll
+	votes       map[string]map[string]int
+	commitments map[string][]string
+	audit       map[string][]domain.AuditEntry
+	history     map[string][]domain.TallySnapshot
 }
 
 func NewMockRepository() *MockRepository {
 	return &MockRepository{
-		polls: make(map[string]*domain.Poll),
-		votes: make(map[string]map[string]int),
+		polls:       make(map[string]*domain.Poll),
+		votes:       make(map[string]map[string]int),
+		commitments: make(map[string][]string),
+		audit:       make(map[string][]domain.AuditEntry),
+		history:     make(map[string][]domain.TallySnapshot),
 	}
 }
 
-func (m *MockRepository) CreatePoll(poll domain.Poll) error {
+func (m *MockRepository) CreatePoll(ctx context.Context, poll domain.Poll) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	m.polls[poll.ID] = &poll
 	m.votes[poll.ID] = make(map[string]int)
 	return nil
 }
 
-func (m *MockRepository) GetPoll(id string) (domain.Poll, error) {
+func (m *MockRepository) GetPoll(ctx context.Context, id string) (domain.Poll, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Poll{}, err
+	}
 	poll, ok := m.polls[id]
 	if !ok {
-		return domain.Poll{}, fmt.Errorf("poll not found")
+		return domain.Poll{}, domain.ErrPollNotFound
 	}
 	return *poll, nil
 }
 
-func (m *MockRepository) Vote(vote domain.Vote) error {
+func (m *MockRepository) Vote(ctx context.Context, vote domain.Vote, commitment string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if _, ok := m.votes[vote.PollID]; !ok {
-		return fmt.Errorf("poll not found")
+		return domain.ErrPollNotFound
 	}
 	m.votes[vote.PollID][vote.Option]++
+
+	var prev *domain.AuditEntry
+	if log := m.audit[vote.PollID]; len(log) > 0 {
+		prev = &log[len(log)-1]
+	}
+	m.audit[vote.PollID] = append(m.audit[vote.PollID], domain.NewAuditEntry(prev, vote, time.Now()))
+	m.commitments[vote.PollID] = append(m.commitments[vote.PollID], commitment)
 	return nil
 }
 
-func (m *MockRepository) GetResults(pollID string) (domain.PollResult, error) {
+func (m *MockRepository) GetResults(ctx context.Context, pollID string) (domain.PollResult, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.PollResult{}, err
+	}
 	poll, ok := m.polls[pollID]
 	if !ok {
-		return domain.PollResult{}, fmt.Errorf("poll not found")
+		return domain.PollResult{}, domain.ErrPollNotFound
+	}
+	result := domain.PollResult{
+		Poll:       *poll,
+		Results:    maps.Clone(m.votes[pollID]),
+		Version:    uint64(len(m.audit[pollID])),
+		CapturedAt: time.Now(),
+	}
+	if log := m.audit[pollID]; len(log) > 0 {
+		result.UpdatedAt = log[len(log)-1].Timestamp
+	}
+	return result, nil
+}
+
+func (m *MockRepository) GetCommitments(ctx context.Context, pollID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := m.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	return m.commitments[pollID], nil
+}
+
+func (m *MockRepository) GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := m.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
+	}
+	return m.audit[pollID], nil
+}
+
+func (m *MockRepository) AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := m.polls[pollID]; !ok {
+		return domain.ErrPollNotFound
+	}
+	m.history[pollID] = append(m.history[pollID], snap)
+	return nil
+}
+
+func (m *MockRepository) GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := m.polls[pollID]; !ok {
+		return nil, domain.ErrPollNotFound
 	}
-	return domain.PollResult{
-		Poll:    *poll,
-		Results: m.votes[pollID],
-	}, nil
+	return m.history[pollID], nil
 }
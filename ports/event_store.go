@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"polling-system/domain"
+)
+
+// EventStore keeps every poll's events as an append-only stream.
+type EventStore interface {
+	// Append adds events to the poll's stream if it holds exactly expected
+	// events so far, and returns domain.ErrStreamConflict otherwise.
+	Append(ctx context.Context, pollID string, expected int, events ...domain.PollEvent) error
+	// Load returns the poll's stream in order; it is empty for unknown polls.
+	Load(ctx context.Context, pollID string) ([]domain.PollEvent, error)
+}
+
+// PollHistory is implemented by repositories that keep a poll's full history
+// rather than just its current state. Decorators that wrap a repository
+// without it return domain.ErrNoHistory.
+type PollHistory interface {
+	ClosePoll(ctx context.Context, pollID string) error
+	// RetractVote withdraws the latest counted vote of voterID if nonce opens
+	// its ballot, and returns domain.ErrNotBallotOwner otherwise.
+	RetractVote(ctx context.Context, pollID, voterID, nonce string) error
+	// ResultsAt returns the tally as it stood at the given time.
+	ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error)
+	Events(ctx context.Context, pollID string) ([]domain.PollEvent, error)
+}
@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+
+	"polling-system/domain"
+)
+
+// EventBus carries tally change notifications between service instances so
+// live updates reach subscribers connected to any of them. Handlers are
+// called from the bus's own goroutines and must not block.
+type EventBus interface {
+	Publish(ctx context.Context, event domain.TallyChanged) error
+	// Subscribe registers handler for every published event, including the
+	// caller's own, until the returned function is called.
+	Subscribe(handler func(domain.TallyChanged)) (unsubscribe func(), err error)
+	Close() error
+}
@@ -1,10 +1,37 @@
 package ports
 
-import "polling-system/domain"
+import (
+	"context"
 
+	"polling-system/domain"
+)
+
+// PollRepository methods take the caller's context first. Implementations return
+// ctx.Err() once it is done and must not apply a write after that.
 type PollRepository interface {
-	CreatePoll(poll domain.Poll) error
-	GetPoll(id string) (domain.Poll, error)
-	Vote(vote domain.Vote) error
-	GetResults(pollID string) (domain.PollResult, error)
+	CreatePoll(ctx context.Context, poll domain.Poll) error
+	GetPoll(ctx context.Context, id string) (domain.Poll, error)
+	// Vote counts the vote and records its receipt commitment in the same
+	// write, so a ballot is never counted without a commitment or vice versa.
+	Vote(ctx context.Context, vote domain.Vote, commitment string) error
+	GetResults(ctx context.Context, pollID string) (domain.PollResult, error)
+	GetCommitments(ctx context.Context, pollID string) ([]string, error)
+	GetAuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error)
+	// AddTallySnapshot records the poll's tally at a moment; GetTallyHistory
+	// returns every one recorded, oldest first.
+	AddTallySnapshot(ctx context.Context, pollID string, snap domain.TallySnapshot) error
+	GetTallyHistory(ctx context.Context, pollID string) ([]domain.TallySnapshot, error)
+}
+
+// Flusher is implemented by repositories that buffer writes and must persist
+// them before the process exits.
+type Flusher interface {
+	Flush() error
+}
+
+// ReadinessChecker is implemented by repositories that can report whether
+// they are able to serve requests, e.g. that their backing store is
+// reachable and loaded.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
 }
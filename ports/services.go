@@ -1,10 +1,34 @@
 package ports
 
-import "polling-system/domain"
+import (
+	"context"
+	"time"
 
+	"polling-system/domain"
+)
+
+// PollService methods take the caller's context first. Implementations return
+// ctx.Err() once it is done and must not apply a write after that.
 type PollService interface {
-	CreatePoll(poll domain.Poll) error
-	Vote(vote domain.Vote) error
-	GetResults(pollID string) (domain.PollResult, error)
-	VoteMultiple(votes domain.MultiVote) error
+	CreatePoll(ctx context.Context, poll domain.Poll) error
+	Vote(ctx context.Context, vote domain.Vote) (domain.Ballot, error)
+	GetResults(ctx context.Context, pollID string) (domain.PollResult, error)
+	VoteMultiple(ctx context.Context, votes domain.MultiVote) ([]domain.Ballot, error)
+	TallyCommitment(ctx context.Context, pollID string) (domain.TallyCommitment, error)
+	VerifyReceipt(ctx context.Context, receipt domain.Receipt) (domain.ReceiptVerification, error)
+	AuditLog(ctx context.Context, pollID string) ([]domain.AuditEntry, error)
+	// ResultsChanged returns a channel that is closed at the next change to
+	// the poll's tally. Take it before reading the results so that no
+	// change in between goes unnoticed.
+	ResultsChanged(ctx context.Context, pollID string) (<-chan struct{}, error)
+	// ClosePoll, RetractVote, ResultsAt and PollEvents need a repository
+	// that keeps each poll's history (ports.PollHistory); with any other
+	// they return domain.ErrNoHistory.
+	ClosePoll(ctx context.Context, pollID string) error
+	RetractVote(ctx context.Context, pollID, voterID, nonce string) error
+	ResultsAt(ctx context.Context, pollID string, at time.Time) (domain.PollResult, error)
+	PollEvents(ctx context.Context, pollID string) ([]domain.PollEvent, error)
+	// TallyHistory returns the poll's recorded tally snapshots followed by
+	// its current tally, thinned by domain.DownsampleHistory.
+	TallyHistory(ctx context.Context, pollID string, step time.Duration, maxPoints int) (domain.TallyHistory, error)
 }